@@ -1,12 +1,16 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
-	"strconv"
+	"time"
 
-	"github.com/peterhellberg/link" //RFC5988 complient header parser
+	"github.com/doodles526/gogitpr/telemetry"
 	"github.com/sirupsen/logrus"
 )
 
@@ -31,25 +35,83 @@ const (
 type GithubAPI interface {
 	PullRequest() PullRequest
 	Repos() Repo
+	Statuses() Status
+	Issues() Issue
+	Milestones() Milestone
+	Users() User
+	Search() Search
+
+	// WithContext returns a copy of the client whose requests are made with
+	// ctx, so are cancelled along with it
+	WithContext(ctx context.Context) GithubAPI
 }
 
 type ghAPI struct {
-	baseURL   *url.URL
-	token     string
-	userAgent string
-	version   Version
+	baseURL    *url.URL
+	token      string
+	userAgent  string
+	version    Version
+	apiVersion string
+
+	headers    http.Header
+	mediaTypes map[string]string
 
 	client *http.Client
 	logger *logrus.Entry
+	// metrics is nil unless a Registry was given
+	metrics *apiMetrics
+	// retrier is nil unless retries are enabled
+	retrier *retrier
+	// ctx is nil unless set by WithContext
+	ctx context.Context
+
+	pagination         Pagination
+	endpointPagination map[string]Pagination
+	perPage            int
 }
 
 // GithubAPIArgs specifies how the github API should be queried
 type GithubAPIArgs struct {
-	BaseURL         string
-	Token           string
+	BaseURL string
+	Token   string
+	// ApplicationName is sent as the User-Agent of every request
 	ApplicationName string
 	Version         Version
-	Logger          *logrus.Logger
+	// APIVersion is the date version of the REST API requested. Defaults to
+	// DefaultAPIVersion
+	APIVersion string
+	Logger     *logrus.Logger
+
+	// Headers are set on every request, overriding our defaults
+	Headers http.Header
+	// MediaTypes are the media types requested from endpoints rather than
+	// MediaTypeJSON, keyed by templates such as "/repos/{owner}/{repo}/pulls",
+	// e.g. to opt in to previews
+	MediaTypes map[string]string
+
+	// Registry, if set, has every request recorded in it
+	Registry *telemetry.Registry
+
+	// Retry, if set, retries requests which fail transiently. See
+	// DefaultRetryPolicy
+	Retry *RetryPolicy
+
+	// Client, if set, is copied to make requests, e.g. to configure proxies
+	// or TLS. Defaults to a new http.Client
+	Client *http.Client
+	// Middleware wraps the transport of Client, the first outermost. See
+	// DebugLogging and Timeout
+	Middleware []Middleware
+
+	// Pagination is how lists are paginated, requesting pages by number by
+	// default
+	Pagination Pagination
+	// EndpointPagination overrides Pagination for endpoints, given as
+	// templates such as "/repos/{owner}/{repo}/pulls" or "/search/issues"
+	EndpointPagination map[string]Pagination
+	// PerPage is the page size lists are requested in, at most 100. Defaults
+	// to github's default of 30
+	PerPage int
 }
 
 // NewGithubAPI creates a new client for accessing the github api
@@ -68,8 +130,19 @@ func NewGithubAPI(args *GithubAPIArgs) (GithubAPI, error) {
 		token:     args.Token,
 		userAgent: args.ApplicationName,
 		version:   args.Version,
-		client:    &http.Client{},
-		logger:    args.Logger.WithFields(logrus.Fields{"prefix": "GithubAPI"}),
+
+		apiVersion: args.APIVersion,
+		headers:    args.Headers,
+		mediaTypes: args.MediaTypes,
+
+		client:  newClient(args.Client, args.Middleware),
+		logger:  args.Logger.WithFields(logrus.Fields{"prefix": "GithubAPI"}),
+		metrics: newAPIMetrics(args.Registry),
+		retrier: newRetrier(args.Retry),
+
+		pagination:         args.Pagination,
+		endpointPagination: args.EndpointPagination,
+		perPage:            args.PerPage,
 	}
 
 	return base, nil
@@ -91,9 +164,48 @@ func (a *GithubAPIArgs) validate() error {
 		return argMissingError("ApplicationName")
 	}
 
+	if len(a.APIVersion) == 0 {
+		a.APIVersion = DefaultAPIVersion
+	}
+
+	if err := a.Pagination.validate(); err != nil {
+		return err
+	}
+	for _, pagination := range a.EndpointPagination {
+		if err := pagination.validate(); err != nil {
+			return err
+		}
+	}
+
+	if a.PerPage < 0 || a.PerPage > maxPerPage {
+		return argUnsupported("PerPage", a.PerPage)
+	}
+
+	if a.Retry != nil {
+		if err := a.Retry.validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+func (g *ghAPI) WithContext(ctx context.Context) GithubAPI {
+	gNew := *g
+	gNew.ctx = ctx
+
+	return &gNew
+}
+
+// context is the context requests are made with
+func (g *ghAPI) context() context.Context {
+	if g.ctx == nil {
+		return context.Background()
+	}
+
+	return g.ctx
+}
+
 func (g *ghAPI) PullRequest() PullRequest {
 	return &pullRequest{
 		g: g,
@@ -106,10 +218,63 @@ func (g *ghAPI) Repos() Repo {
 	}
 }
 
+func (g *ghAPI) Statuses() Status {
+	return &status{
+		g: g,
+	}
+}
+
+func (g *ghAPI) Issues() Issue {
+	return &issue{
+		g: g,
+	}
+}
+
+func (g *ghAPI) Milestones() Milestone {
+	return &milestone{
+		g: g,
+	}
+}
+
+func (g *ghAPI) Users() User {
+	return &user{
+		g: g,
+	}
+}
+
+func (g *ghAPI) Search() Search {
+	return &search{
+		g: g,
+	}
+}
+
+// repoNames returns the names of every repo matching args
+func (g *ghAPI) repoNames(args *RepoArgs) ([]string, error) {
+	repos, err := g.Repos().Get(args)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		names = append(names, repo.Name)
+	}
+
+	return names, nil
+}
+
 type requestArgs struct {
 	values   map[string]string
 	endpoint string
 	method   string
+	// accept, when set, is the media type requested rather than the
+	// endpoint's
+	accept string
+	// url, when set, is requested verbatim rather than endpoint and values,
+	// e.g. to follow a Link. endpoint still names the request
+	url string
+	// body is encoded as JSON when set
+	body interface{}
 }
 
 func deepCopyURL(u *url.URL) *url.URL {
@@ -121,89 +286,144 @@ func deepCopyURL(u *url.URL) *url.URL {
 func deepCopyRequestArgs(a *requestArgs) *requestArgs {
 	aNew := new(requestArgs)
 	*aNew = *a
+
+	if a.values != nil {
+		aNew.values = make(map[string]string, len(a.values))
+		for key, val := range a.values {
+			aNew.values[key] = val
+		}
+	}
+
 	return aNew
 }
 
 // doRequest performs data request from args
 func (g *ghAPI) doRequest(args *requestArgs) (*http.Response, error) {
-	u := deepCopyURL(g.baseURL)
-
 	g.logger.Debugf("performing request - %s %s", args.method, args.endpoint)
 
-	u.Path = fmt.Sprintf("%s%s", u.Path, args.endpoint)
+	u, err := g.requestURL(args)
+	if err != nil {
+		return nil, err
+	}
 
-	if args.values != nil {
-		for key, val := range args.values {
-			u.Query().Set(key, val)
+	var body []byte
+	if args.body != nil {
+		buf, err := json.Marshal(args.body)
+		if err != nil {
+			return nil, err
 		}
+		body = buf
 	}
 
-	req, err := http.NewRequest(args.method, u.String(), nil)
+	for attempt := 1; ; attempt++ {
+		resp, err := g.attempt(args, u.String(), body)
+		if err == nil && resp.StatusCode < 400 {
+			return resp, nil
+		}
+
+		delay, retry := g.retrier.retry(args.method, attempt, resp, err)
+		if !retry {
+			if err != nil {
+				return nil, err
+			}
+			return nil, newResponseError(resp)
+		}
+
+		if err == nil {
+			err = newResponseError(resp)
+		}
+		g.logger.Warnf("retrying %s %s in %s after attempt %d - %v", args.method, args.endpoint, delay, attempt, err)
+		if err := g.retrier.sleep(g.context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// requestURL is the URL args requests
+func (g *ghAPI) requestURL(args *requestArgs) (*url.URL, error) {
+	if len(args.url) != 0 {
+		return url.Parse(args.url)
+	}
+
+	u := deepCopyURL(g.baseURL)
+	// endpoints are escaped, so a name such as a branch holding a slash stays
+	// a single segment
+	unescaped, err := url.PathUnescape(args.endpoint)
 	if err != nil {
 		return nil, err
 	}
+	u.RawPath = fmt.Sprintf("%s%s", u.EscapedPath(), args.endpoint)
+	u.Path = fmt.Sprintf("%s%s", u.Path, unescaped)
 
-	if len(g.token) != 0 {
-		req.Header.Set("Authorization", fmt.Sprintf("token %s", g.token))
+	if args.values != nil {
+		// u.Query() returns a copy, so the values must be encoded back
+		query := u.Query()
+		for key, val := range args.values {
+			query.Set(key, val)
+		}
+		u.RawQuery = query.Encode()
 	}
 
-	return g.client.Do(req)
+	return u, nil
 }
 
-type processFunc func(*http.Response) error
-
-func (g *ghAPI) doFullPagination(args *requestArgs, f processFunc) error {
-	nArgs := deepCopyRequestArgs(args)
-
-	if nArgs.values == nil {
-		nArgs.values = make(map[string]string)
+// attempt makes a single request, which is a new *http.Request each time as
+// one can't be reused once sent
+func (g *ghAPI) attempt(args *requestArgs, u string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
 	}
 
-	resp, err := g.doRequest(nArgs)
+	req, err := http.NewRequestWithContext(g.context(), args.method, u, bodyReader)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	totalPages := 1
-
-	// We have to process this now since passing it to f() could alter the header
-	// and we need to ensure someone isn't able to alter the Link header before this
-	if linkGroup := link.ParseHeader(resp.Header); linkGroup != nil {
-		if last, ok := linkGroup["last"]; ok {
-			linkURL, err := url.Parse(last.URI)
-			if err != nil {
-				return err
-			}
-			if pageOfLast := linkURL.Query().Get("page"); pageOfLast != "" {
-				iPageOfLast, err := strconv.Atoi(pageOfLast)
-				if err != nil {
-					return err
-				}
-				totalPages = iPageOfLast
-			}
-		}
+	g.setHeaders(req, args)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
-	if err := f(resp); err != nil {
-		return err
-	}
+	start := time.Now()
+	resp, err := g.client.Do(req)
+	g.metrics.observe(args.method, args.endpoint, start, resp)
 
-	for curPage := 2; curPage <= totalPages; curPage++ {
-		nArgs.values["page"] = strconv.Itoa(curPage)
+	return resp, err
+}
 
-		resp, err := g.doRequest(nArgs)
-		if err != nil {
-			return err
-		}
+// newResponseError consumes and closes the body of resp, returning the
+// message github gave for the failure
+func newResponseError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	body := struct {
+		Message string `json:"message"`
+	}{}
+	// a body we can't decode still leaves us with the status code
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	return &ResponseError{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Message:    body.Message,
+	}
+}
 
-		if err := f(resp); err != nil {
-			return err
-		}
+// doJSON performs a single request from args, decoding the response into v
+func (g *ghAPI) doJSON(args *requestArgs, v interface{}) error {
+	resp, err := g.doRequest(args)
+	if err != nil {
+		return err
 	}
+	defer resp.Body.Close()
 
-	return nil
+	return json.NewDecoder(resp.Body).Decode(v)
 }
 
+type processFunc func(*http.Response) error
+
 func argMissingError(field string) error {
 	return fmt.Errorf("%s must be set in GithubAPIArgs", field)
 }
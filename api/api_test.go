@@ -1,14 +1,89 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
+	"github.com/doodles526/gogitpr/githubtest"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
+// newTestAPI returns a ghAPI which sends all requests to a local server
+// running handler. The caller must close the returned server
+func newTestAPI(handler http.Handler) (*ghAPI, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	bu, _ := url.Parse(server.URL)
+
+	g := &ghAPI{
+		baseURL:   bu,
+		userAgent: "pr-test-code",
+		version:   Version3,
+		client:    server.Client(),
+		logger:    logrus.New().WithFields(logrus.Fields{"prefix": "TEST_API"}),
+	}
+
+	return g, server
+}
+
+// newFakeAPI returns a ghAPI which sends all requests to a fake github. The
+// caller must close the returned server
+func newFakeAPI() (*ghAPI, *githubtest.Server) {
+	server := githubtest.NewServer()
+	bu, _ := url.Parse(server.URL)
+
+	g := &ghAPI{
+		baseURL:   bu,
+		userAgent: "pr-test-code",
+		version:   Version3,
+		client:    server.Client(),
+		logger:    logrus.New().WithFields(logrus.Fields{"prefix": "TEST_API"}),
+	}
+
+	return g, server
+}
+
+func TestDoRequestResponseError(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	defer server.Close()
+
+	_, err := g.doRequest(&requestArgs{method: "GET", endpoint: "/repos/octocat/missing"})
+	assert.Error(t, err, "A 404 should be an error")
+	assert.True(t, IsNotFound(err), "Error should be a 404 ResponseError")
+	assert.Contains(t, err.Error(), "Not Found")
+}
+
+func TestWithContext(t *testing.T) {
+	requests := 0
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"login": "octocat"}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gh := g.WithContext(ctx)
+
+	_, err := gh.Users().Get("octocat")
+	assert.NoError(t, err)
+
+	cancel()
+	_, err = gh.Users().Get("octocat")
+	assert.True(t, errors.Is(err, context.Canceled), "Requests should be cancelled with their context")
+	assert.Equal(t, 1, requests)
+
+	_, err = g.Users().Get("octocat")
+	assert.NoError(t, err, "The original client should be unaffected")
+}
+
 func TestDeepCopyURL(t *testing.T) {
 	u := &url.URL{
 		Host: "localhost:8080",
@@ -34,7 +109,11 @@ func TestDeepCopyRequestArgs(t *testing.T) {
 }
 
 func TestDoPagination(t *testing.T) {
-	// TODO: Mock out a local server to test rather than hitting github
+	// a hand-written fixture in the cassette format, so it's replayed even
+	// when recording
+	cassette, err := githubtest.LoadCassette("testdata/do_pagination.json")
+	assert.NoError(t, err)
+
 	g := &ghAPI{
 		baseURL: &url.URL{
 			Host:   "api.github.com",
@@ -42,7 +121,7 @@ func TestDoPagination(t *testing.T) {
 		},
 		userAgent: "pr-test-code",
 		version:   Version3,
-		client:    &http.Client{},
+		client:    &http.Client{Transport: cassette.Replay()},
 		logger:    logrus.New().WithFields(logrus.Fields{"prefix": "TEST_API"}),
 	}
 
@@ -51,11 +130,37 @@ func TestDoPagination(t *testing.T) {
 		endpoint: "/repos/coreos/etcd/pulls",
 	}
 
-	reqFunc := func(resp *http.Response) error {
-		resp.Body.Close()
-		return nil
+	prs := make([]PullRequestData, 0)
+	err = g.doFullPagination(a, extractPRs(&prs))
+	assert.NoError(t, err)
+	assert.Equal(t, 35, len(prs), "Both pages should be fetched")
+	assert.Equal(t, 35, prs[34].Number)
+}
+
+func TestDoPaginationFakeServer(t *testing.T) {
+	g, server := newFakeAPI()
+	defer server.Close()
+
+	items := make([]string, 0, 65)
+	for i := 1; i <= 65; i++ {
+		items = append(items, fmt.Sprintf(`{"id": %d, "number": %d}`, i, i))
+	}
+	server.HandleList("/repos/coreos/etcd/pulls", items...)
+
+	a := &requestArgs{
+		method:   "GET",
+		endpoint: "/repos/coreos/etcd/pulls",
 	}
 
-	err := g.doFullPagination(a, reqFunc)
+	prs := make([]PullRequestData, 0)
+	err := g.doFullPagination(a, extractPRs(&prs))
 	assert.NoError(t, err)
+	assert.Equal(t, 65, len(prs))
+	for i, pr := range prs {
+		assert.Equal(t, i+1, pr.Number, "PRs should be in page order")
+	}
+	assert.Equal(t, 3, server.Requests("GET", "/repos/coreos/etcd/pulls"))
+
+	err = g.doFullPagination(&requestArgs{method: "GET", endpoint: "/repos/coreos/missing/pulls"}, extractPRs(&prs))
+	assert.True(t, IsNotFound(err))
 }
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// Branches fetches every branch of owner/repo
+func (r *repo) Branches(owner, repo string) ([]BranchData, error) {
+	if len(owner) == 0 || len(repo) == 0 {
+		return nil, ErrOwnerRepo
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: fmt.Sprintf("/repos/%s/%s/branches", owner, repo),
+		method:   "GET",
+	}
+
+	branches := make([]BranchData, 0)
+	if err := r.g.doFullPagination(reqArgs, extractBranches(&branches)); err != nil {
+		return nil, err
+	}
+
+	return branches, nil
+}
+
+// BranchProtection fetches the protection rules of branch in owner/repo. This
+// needs admin rights on the repo. ErrBranchNotProtected is returned for a
+// branch without protection
+func (r *repo) BranchProtection(owner, repo, branch string) (*BranchProtectionData, error) {
+	if len(owner) == 0 || len(repo) == 0 {
+		return nil, ErrOwnerRepo
+	}
+	if len(branch) == 0 {
+		return nil, ErrBranch
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: fmt.Sprintf("/repos/%s/%s/branches/%s/protection", owner, repo, url.PathEscape(branch)),
+		method:   "GET",
+	}
+
+	protection := new(BranchProtectionData)
+	if err := r.g.doJSON(reqArgs, protection); err != nil {
+		if IsNotFound(err) {
+			return nil, ErrBranchNotProtected
+		}
+		return nil, err
+	}
+
+	return protection, nil
+}
+
+func extractBranches(branches *[]BranchData) processFunc {
+	return func(resp *http.Response) error {
+		defer resp.Body.Close()
+
+		branchTmp := make([]BranchData, 0)
+
+		decoder := json.NewDecoder(resp.Body)
+
+		if err := decoder.Decode(&branchTmp); err != nil {
+			return err
+		}
+		*branches = append(*branches, branchTmp...)
+
+		return nil
+	}
+}
+
+// BranchData represents a branch of a repo
+type BranchData struct {
+	Name   string `json:"name"`
+	Commit struct {
+		Sha string `json:"sha"`
+		URL string `json:"url"`
+	} `json:"commit"`
+	Protected     bool   `json:"protected"`
+	ProtectionURL string `json:"protection_url"`
+}
+
+// BranchProtectionData represents the protection rules of a branch. Rules
+// which aren't enabled are nil
+type BranchProtectionData struct {
+	URL                        string                    `json:"url"`
+	RequiredStatusChecks       *RequiredStatusChecksData `json:"required_status_checks"`
+	RequiredPullRequestReviews *RequiredReviewsData      `json:"required_pull_request_reviews"`
+	Restrictions               *BranchRestrictionsData   `json:"restrictions"`
+	EnforceAdmins              *ProtectionSettingData    `json:"enforce_admins"`
+	RequiredLinearHistory      *ProtectionSettingData    `json:"required_linear_history"`
+	AllowForcePushes           *ProtectionSettingData    `json:"allow_force_pushes"`
+	AllowDeletions             *ProtectionSettingData    `json:"allow_deletions"`
+	RequiredSignatures         *ProtectionSettingData    `json:"required_signatures"`
+	RequiredConversations      *ProtectionSettingData    `json:"required_conversation_resolution"`
+	LockBranch                 *ProtectionSettingData    `json:"lock_branch"`
+	AllowForkSyncing           *ProtectionSettingData    `json:"allow_fork_syncing"`
+	BlockCreations             *ProtectionSettingData    `json:"block_creations"`
+}
+
+// RequiredStatusChecksData are the statuses and check runs which must pass
+// before a pull request is merged
+type RequiredStatusChecksData struct {
+	// Strict requires the branch to be up to date with the base first
+	Strict bool `json:"strict"`
+	// Contexts are the names of the required statuses and check runs. Checks
+	// gives the same names along with the app which must report them
+	Contexts []string `json:"contexts"`
+	Checks   []struct {
+		Context string `json:"context"`
+		AppID   *int   `json:"app_id"`
+	} `json:"checks"`
+}
+
+// RequiredReviewsData are the reviews a pull request needs before it's merged
+type RequiredReviewsData struct {
+	DismissStaleReviews          bool `json:"dismiss_stale_reviews"`
+	RequireCodeOwnerReviews      bool `json:"require_code_owner_reviews"`
+	RequiredApprovingReviewCount int  `json:"required_approving_review_count"`
+	RequireLastPushApproval      bool `json:"require_last_push_approval"`
+}
+
+// BranchRestrictionsData are who may push to a branch
+type BranchRestrictionsData struct {
+	Users []UserData `json:"users"`
+	Teams []TeamData `json:"teams"`
+}
+
+// ProtectionSettingData is a protection rule which is either on or off
+type ProtectionSettingData struct {
+	Enabled bool `json:"enabled"`
+}
+
+// RequiredContexts returns the names of the statuses and check runs which
+// must pass, sorted and without duplicates
+func (b *BranchProtectionData) RequiredContexts() []string {
+	contexts := make([]string, 0)
+	if b.RequiredStatusChecks == nil {
+		return contexts
+	}
+
+	seen := make(map[string]bool)
+	add := func(context string) {
+		if !seen[context] {
+			seen[context] = true
+			contexts = append(contexts, context)
+		}
+	}
+	for _, context := range b.RequiredStatusChecks.Contexts {
+		add(context)
+	}
+	for _, check := range b.RequiredStatusChecks.Checks {
+		add(check.Context)
+	}
+	sort.Strings(contexts)
+
+	return contexts
+}
+
+// RequiredApprovals returns how many approving reviews a pull request needs
+func (b *BranchProtectionData) RequiredApprovals() int {
+	if b.RequiredPullRequestReviews == nil {
+		return 0
+	}
+
+	return b.RequiredPullRequestReviews.RequiredApprovingReviewCount
+}
+
+// MissingContexts returns the required contexts which haven't succeeded on a
+// commit with the given combined status and check runs, sorted
+func (b *BranchProtectionData) MissingContexts(combined *CombinedStatusData, checkRuns []CheckRunData) []string {
+	passed := make(map[string]bool)
+	if combined != nil {
+		for _, status := range combined.Statuses {
+			passed[status.Context] = status.State == "success"
+		}
+	}
+	for _, run := range checkRuns {
+		passed[run.Name] = run.CIState() == CIStateSuccess
+	}
+
+	missing := make([]string, 0)
+	for _, context := range b.RequiredContexts() {
+		if !passed[context] {
+			missing = append(missing, context)
+		}
+	}
+
+	return missing
+}
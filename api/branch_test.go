@@ -0,0 +1,95 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoBranches(t *testing.T) {
+	g, server := newFakeAPI()
+	defer server.Close()
+
+	server.HandleList("/repos/octocat/Hello-World/branches",
+		`{"name": "main", "commit": {"sha": "c5b97d5ae6c19d5c5df71a34c7fbeeda2479ccbc"}, "protected": true}`,
+		`{"name": "feature", "commit": {"sha": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}, "protected": false}`,
+	)
+
+	branches, err := g.Repos().Branches("octocat", "Hello-World")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(branches))
+	assert.Equal(t, "main", branches[0].Name)
+	assert.True(t, branches[0].Protected)
+	assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", branches[1].Commit.Sha)
+
+	_, err = g.Repos().Branches("octocat", "")
+	assert.Equal(t, ErrOwnerRepo, err)
+}
+
+func TestRepoBranchProtection(t *testing.T) {
+	g, server := newFakeAPI()
+	defer server.Close()
+
+	server.HandleObject("GET", "/repos/octocat/Hello-World/branches/main/protection", http.StatusOK, `{
+		"required_status_checks": {"strict": true, "contexts": ["ci/build", "lint"], "checks": [{"context": "ci/build", "app_id": null}, {"context": "test", "app_id": 15368}]},
+		"required_pull_request_reviews": {"dismiss_stale_reviews": true, "require_code_owner_reviews": true, "required_approving_review_count": 2},
+		"enforce_admins": {"enabled": true},
+		"allow_force_pushes": {"enabled": false}
+	}`)
+	server.HandleObject("GET", "/repos/octocat/Hello-World/branches/feature/protection", http.StatusNotFound, `{"message": "Branch not protected"}`)
+
+	protection, err := g.Repos().BranchProtection("octocat", "Hello-World", "main")
+	assert.NoError(t, err)
+	assert.True(t, protection.RequiredStatusChecks.Strict)
+	assert.True(t, protection.RequiredPullRequestReviews.RequireCodeOwnerReviews)
+	assert.True(t, protection.EnforceAdmins.Enabled)
+	assert.False(t, protection.AllowForcePushes.Enabled)
+	assert.Nil(t, protection.Restrictions, "Rules which aren't enabled should be nil")
+	assert.Equal(t, []string{"ci/build", "lint", "test"}, protection.RequiredContexts())
+	assert.Equal(t, 2, protection.RequiredApprovals())
+
+	_, err = g.Repos().BranchProtection("octocat", "Hello-World", "feature")
+	assert.Equal(t, ErrBranchNotProtected, err)
+
+	_, err = g.Repos().BranchProtection("octocat", "Hello-World", "")
+	assert.Equal(t, ErrBranch, err)
+}
+
+func TestRepoBranchProtectionEscapesBranch(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/octocat/Hello-World/branches/release%2F1.0/protection", r.URL.EscapedPath(), "The branch should be a single segment")
+		fmt.Fprint(w, `{"required_status_checks": {"contexts": ["ci/build"]}}`)
+	}))
+	defer server.Close()
+
+	protection, err := g.Repos().BranchProtection("octocat", "Hello-World", "release/1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ci/build"}, protection.RequiredContexts())
+
+	assert.Equal(t, "/repos/{owner}/{repo}/branches/{branch}/protection",
+		endpointTemplate("/repos/octocat/Hello-World/branches/"+url.PathEscape("release/1.0")+"/protection"),
+		"Branch names shouldn't leak into metric labels")
+}
+
+func TestBranchProtectionMissingContexts(t *testing.T) {
+	protection := &BranchProtectionData{
+		RequiredStatusChecks: &RequiredStatusChecksData{Contexts: []string{"ci/build", "lint", "test", "deploy"}},
+	}
+
+	success, failure := "success", "failure"
+	combined := &CombinedStatusData{Statuses: []StatusData{
+		{Context: "ci/build", State: "success"},
+		{Context: "deploy", State: "pending"},
+	}}
+	checkRuns := []CheckRunData{
+		{Name: "lint", Status: "completed", Conclusion: &success},
+		{Name: "test", Status: "completed", Conclusion: &failure},
+	}
+
+	assert.Equal(t, []string{"deploy", "test"}, protection.MissingContexts(combined, checkRuns))
+	assert.Equal(t, []string{"ci/build", "deploy", "lint", "test"}, protection.MissingContexts(nil, nil))
+	assert.Equal(t, []string{}, (&BranchProtectionData{}).MissingContexts(nil, nil), "No contexts should be required without status checks")
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ContentData represents a file, directory, symlink or submodule in a repo
+type ContentData struct {
+	// Type is one of file, dir, symlink or submodule
+	Type string `json:"type"`
+	// Encoding is how github encoded Content. Contents decodes it, leaving
+	// Encoding blank
+	Encoding string `json:"encoding"`
+	Size     int    `json:"size"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	// Content is the content of a file, once decoded by Contents
+	Content     string `json:"content"`
+	Sha         string `json:"sha"`
+	URL         string `json:"url"`
+	GitURL      string `json:"git_url"`
+	HTMLURL     string `json:"html_url"`
+	DownloadURL string `json:"download_url"`
+	// Target is the path a symlink points to
+	Target string `json:"target"`
+	// SubmoduleGitURL is the repo a submodule points to
+	SubmoduleGitURL string `json:"submodule_git_url"`
+
+	// Entries are the contents of a directory, without their Content
+	Entries []ContentData `json:"entries"`
+}
+
+// IsDir reports whether the content is a directory
+func (c ContentData) IsDir() bool {
+	return c.Type == "dir"
+}
+
+// Contents fetches the file or directory at path in owner/repo, as of ref.
+// The repo's default branch is read if ref is blank, and its root directory if
+// path is. The content of a file is returned decoded
+func (r *repo) Contents(owner, repo, path, ref string) (*ContentData, error) {
+	if len(owner) == 0 || len(repo) == 0 {
+		return nil, ErrOwnerRepo
+	}
+
+	endpoint := fmt.Sprintf("/repos/%s/%s/contents", owner, repo)
+	if path = strings.Trim(path, "/"); len(path) != 0 {
+		segments := strings.Split(path, "/")
+		for idx := range segments {
+			segments[idx] = url.PathEscape(segments[idx])
+		}
+		endpoint = fmt.Sprintf("%s/%s", endpoint, strings.Join(segments, "/"))
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: endpoint,
+		method:   "GET",
+	}
+	if len(ref) != 0 {
+		reqArgs.values = map[string]string{"ref": ref}
+	}
+
+	var raw json.RawMessage
+	if err := r.g.doJSON(reqArgs, &raw); err != nil {
+		return nil, err
+	}
+
+	// a directory is listed as an array of its entries
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		dir := &ContentData{
+			Type:    "dir",
+			Path:    path,
+			Name:    path[strings.LastIndex(path, "/")+1:],
+			Entries: make([]ContentData, 0),
+		}
+		if err := json.Unmarshal(raw, &dir.Entries); err != nil {
+			return nil, err
+		}
+
+		return dir, nil
+	}
+
+	content := new(ContentData)
+	if err := json.Unmarshal(raw, content); err != nil {
+		return nil, err
+	}
+
+	if err := r.decodeContent(content, reqArgs); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// decodeContent decodes the content of a file fetched with reqArgs. Files
+// over 1MB are returned without their content, so are fetched again raw
+func (r *repo) decodeContent(content *ContentData, reqArgs *requestArgs) error {
+	switch content.Encoding {
+	case "":
+		return nil
+
+	case "base64":
+		// github wraps the encoded content with newlines
+		encoded := strings.NewReplacer("\n", "", "\r", "").Replace(content.Content)
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("decoding %s: %v", content.Path, err)
+		}
+		content.Content = string(decoded)
+
+	case "none":
+		rawArgs := deepCopyRequestArgs(reqArgs)
+		rawArgs.accept = MediaTypeRaw
+
+		text, err := r.g.doText(rawArgs)
+		if err != nil {
+			return err
+		}
+		content.Content = text
+
+	default:
+		return fmt.Errorf("%s has unsupported encoding %q", content.Path, content.Encoding)
+	}
+
+	content.Encoding = ""
+	return nil
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoContentsFile(t *testing.T) {
+	g, server := newFakeAPI()
+	defer server.Close()
+
+	readme := "# Hello World\n\nMy first repo on GitHub! It says hello to the world, and not much else\n"
+	encoded := base64.StdEncoding.EncodeToString([]byte(readme))
+	// github wraps the encoding every 60 characters
+	wrapped := fmt.Sprintf(`%s\n%s\n`, encoded[:60], encoded[60:])
+
+	server.Handle("GET", "/repos/octocat/Hello-World/contents/docs/README.md", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "v1.0", r.URL.Query().Get("ref"))
+		fmt.Fprintf(w, `{"type": "file", "encoding": "base64", "size": %d, "name": "README.md", "path": "docs/README.md", "content": "%s", "sha": "3d21ec53a331a6f037a91c368710b99387d012c1"}`, len(readme), wrapped)
+	}))
+
+	content, err := g.Repos().Contents("octocat", "Hello-World", "/docs/README.md", "v1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, readme, content.Content, "Content should be decoded")
+	assert.Equal(t, "", content.Encoding)
+	assert.Equal(t, "docs/README.md", content.Path)
+	assert.False(t, content.IsDir())
+
+	_, err = g.Repos().Contents("", "Hello-World", "README.md", "")
+	assert.Equal(t, ErrOwnerRepo, err)
+}
+
+func TestRepoContentsLargeFile(t *testing.T) {
+	g, server := newFakeAPI()
+	defer server.Close()
+
+	server.Handle("GET", "/repos/octocat/Hello-World/contents/data.csv", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == MediaTypeRaw {
+			fmt.Fprint(w, "a,b\n1,2\n")
+			return
+		}
+		// files over 1MB are returned without their content
+		fmt.Fprint(w, `{"type": "file", "encoding": "none", "size": 2097152, "name": "data.csv", "path": "data.csv", "content": ""}`)
+	}))
+
+	content, err := g.Repos().Contents("octocat", "Hello-World", "data.csv", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", content.Content, "Large files should be fetched raw")
+	assert.Equal(t, 2, server.Requests("GET", "/repos/octocat/Hello-World/contents/data.csv"))
+}
+
+func TestRepoContentsDir(t *testing.T) {
+	g, server := newFakeAPI()
+	defer server.Close()
+
+	server.HandleObject("GET", "/repos/octocat/Hello-World/contents/docs", http.StatusOK,
+		`[{"type": "file", "name": "README.md", "path": "docs/README.md"}, {"type": "dir", "name": "images", "path": "docs/images"}]`)
+
+	content, err := g.Repos().Contents("octocat", "Hello-World", "docs", "")
+	assert.NoError(t, err)
+	assert.True(t, content.IsDir())
+	assert.Equal(t, "docs", content.Name)
+	assert.Equal(t, 2, len(content.Entries))
+	assert.Equal(t, "docs/images", content.Entries[1].Path)
+	assert.True(t, content.Entries[1].IsDir())
+
+	_, err = g.Repos().Contents("octocat", "Hello-World", "missing", "")
+	assert.True(t, IsNotFound(err))
+
+	server.HandleObject("GET", "/repos/octocat/Hello-World/contents/docs/notes #1?.md", http.StatusOK, `{"type": "file", "path": "docs/notes #1?.md"}`)
+	content, err = g.Repos().Contents("octocat", "Hello-World", "docs/notes #1?.md", "")
+	assert.NoError(t, err, "Paths should be escaped")
+	assert.Equal(t, "docs/notes #1?.md", content.Path)
+}
@@ -1,10 +1,67 @@
 package api
 
 import (
+	"fmt"
+
 	"github.com/pkg/errors"
 )
 
 var (
 	// ErrUserOrg reports if the user and/or organization specified is not valid
 	ErrUserOrg = errors.New("Either User or Org must be set, not both")
+
+	// ErrOwnerRepo reports if the owner or repo name of a request is missing
+	ErrOwnerRepo = errors.New("Both Owner and Repo must be set")
+
+	// ErrRef reports if the commit ref of a request is missing
+	ErrRef = errors.New("Ref must be set")
+
+	// ErrBranch reports if the branch name of a request is missing
+	ErrBranch = errors.New("Branch must be set")
+
+	// ErrNumber reports if the pull request number of a request is missing
+	ErrNumber = errors.New("Number must be set")
+
+	// ErrCreatePullRequest reports if a required field to create a PR is missing
+	ErrCreatePullRequest = errors.New("Title, Head and Base must be set")
+
+	// ErrLogin reports if the user login of a request is missing
+	ErrLogin = errors.New("Login must be set")
+
+	// ErrOrg reports if the organization of a request is missing
+	ErrOrg = errors.New("Org must be set")
+
+	// ErrTeam reports if the team slug of a request is missing
+	ErrTeam = errors.New("Team slug must be set")
+
+	// ErrURL reports if the URL to fetch, such as the DiffURL of a pull
+	// request, is missing
+	ErrURL = errors.New("URL must be set")
+
+	// ErrNoCodeOwners reports if a repo has no CODEOWNERS file
+	ErrNoCodeOwners = errors.New("No CODEOWNERS file found")
+
+	// ErrBranchNotProtected reports if a branch has no protection rules
+	ErrBranchNotProtected = errors.New("Branch not protected")
+
+	// ErrNoReviewers reports if neither reviewers nor team reviewers are given
+	ErrNoReviewers = errors.New("Either Reviewers or TeamReviewers must be set")
 )
+
+// ResponseError is returned when the github api responds with a non 2xx status
+type ResponseError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a ResponseError for a 404
+func IsNotFound(err error) bool {
+	rErr, ok := errors.Cause(err).(*ResponseError)
+	return ok && rErr.StatusCode == 404
+}
@@ -6,5 +6,12 @@ type CommitData struct {
 	Ref   string   `json:"ref"`
 	Sha   string   `json:"sha"`
 	User  UserData `json:"user"`
-	Repo  RepoData `json:"repo"`
+	// Repo is nil when the repository has since been deleted, as is
+	// the case for the head of a PR opened from a removed fork
+	Repo *RepoData `json:"repo"`
+}
+
+// HasRepo reports whether the repository of the commit still exists
+func (c CommitData) HasRepo() bool {
+	return c.Repo != nil
 }
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// Media types requests are made with, see
+// https://docs.github.com/en/rest/overview/media-types
+const (
+	// MediaTypeJSON is the default media type of every request
+	MediaTypeJSON = "application/vnd.github+json"
+	// MediaTypeDiff has pull requests and commits returned as a unified diff
+	MediaTypeDiff = "application/vnd.github.diff"
+	// MediaTypePatch has pull requests and commits returned as a patch
+	MediaTypePatch = "application/vnd.github.patch"
+	// MediaTypeRaw has file contents returned as is
+	MediaTypeRaw = "application/vnd.github.raw"
+)
+
+// DefaultAPIVersion is the version of the REST API requested unless
+// GithubAPIArgs sets another
+const DefaultAPIVersion = "2022-11-28"
+
+// setHeaders sets the headers of req, a request made for args. Headers are
+// set from the least to the most specific: our defaults, the configured
+// default headers, the media type of the endpoint, then the media type of
+// args. Credentials and the API version are only sent to the API itself
+func (g *ghAPI) setHeaders(req *http.Request, args *requestArgs) {
+	req.Header.Set("User-Agent", g.userAgent)
+	req.Header.Set("Accept", MediaTypeJSON)
+
+	toAPI := req.URL.Host == g.baseURL.Host
+	if toAPI && len(g.apiVersion) != 0 {
+		req.Header.Set("X-GitHub-Api-Version", g.apiVersion)
+	}
+
+	for name, values := range g.headers {
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+
+	if mediaType, ok := g.mediaTypes[endpointTemplate(args.endpoint)]; ok {
+		req.Header.Set("Accept", mediaType)
+	}
+	if len(args.accept) != 0 {
+		req.Header.Set("Accept", args.accept)
+	}
+
+	if toAPI && len(g.token) != 0 {
+		req.Header.Set("Authorization", fmt.Sprintf("token %s", g.token))
+	}
+}
+
+// doText performs a single request from args, returning the response as text
+func (g *ghAPI) doText(args *requestArgs) (string, error) {
+	resp, err := g.doRequest(args)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestHeaders(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		fmt.Fprint(w, `{"login": "octocat"}`)
+	}))
+	defer server.Close()
+
+	gh, err := NewGithubAPI(&GithubAPIArgs{
+		BaseURL:         server.URL,
+		Token:           "s3cr3t",
+		ApplicationName: "gogitpr-test",
+		Logger:          logrus.New(),
+		Headers:         http.Header{"X-Team": {"platform"}},
+		MediaTypes:      map[string]string{"/orgs/{org}/teams": "application/vnd.github.hellcat-preview+json"},
+	})
+	assert.NoError(t, err)
+
+	_, err = gh.Users().Get("octocat")
+	assert.NoError(t, err)
+	assert.Equal(t, "gogitpr-test", headers.Get("User-Agent"))
+	assert.Equal(t, MediaTypeJSON, headers.Get("Accept"))
+	assert.Equal(t, DefaultAPIVersion, headers.Get("X-GitHub-Api-Version"))
+	assert.Equal(t, "token s3cr3t", headers.Get("Authorization"))
+	assert.Equal(t, "platform", headers.Get("X-Team"), "Default headers should be sent")
+
+	gh.Users().OrgTeams("octocat")
+	assert.Equal(t, "application/vnd.github.hellcat-preview+json", headers.Get("Accept"), "Endpoints should be requested with their media type")
+
+	gh, err = NewGithubAPI(&GithubAPIArgs{
+		BaseURL:         server.URL,
+		ApplicationName: "gogitpr-test",
+		APIVersion:      "2026-03-10",
+		Logger:          logrus.New(),
+		Headers:         http.Header{"Accept": {"application/json"}},
+	})
+	assert.NoError(t, err)
+
+	gh.Users().Get("octocat")
+	assert.Equal(t, "2026-03-10", headers.Get("X-GitHub-Api-Version"))
+	assert.Equal(t, "application/json", headers.Get("Accept"), "Default headers should override ours")
+	assert.Equal(t, "", headers.Get("Authorization"))
+}
+
+func TestPullRequestRawDiff(t *testing.T) {
+	var headers http.Header
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		switch r.URL.Path {
+		case "/octocat/Hello-World/pull/1347.diff":
+			fmt.Fprint(w, "diff --git a/README b/README\n")
+		case "/octocat/Hello-World/pull/1347.patch":
+			fmt.Fprint(w, "From 6dcb09b5b57875f334f61aebed695e2e4193db5e Mon Sep 17 00:00:00 2001\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer web.Close()
+
+	g, server := newTestAPI(http.NotFoundHandler())
+	defer server.Close()
+	g.token = "s3cr3t"
+
+	pr := &PullRequestData{
+		DiffURL:  web.URL + "/octocat/Hello-World/pull/1347.diff",
+		PatchURL: web.URL + "/octocat/Hello-World/pull/1347.patch",
+	}
+
+	diff, err := g.PullRequest().RawDiff(pr)
+	assert.NoError(t, err)
+	assert.Equal(t, "diff --git a/README b/README\n", diff)
+	assert.Equal(t, "", headers.Get("Authorization"), "The token should only be sent to the API")
+	assert.Equal(t, "", headers.Get("X-GitHub-Api-Version"))
+	assert.Equal(t, "pr-test-code", headers.Get("User-Agent"))
+
+	patch, err := g.PullRequest().RawPatch(pr)
+	assert.NoError(t, err)
+	assert.Contains(t, patch, "From 6dcb09b5b57875f334f61aebed695e2e4193db5e")
+
+	_, err = g.PullRequest().RawDiff(&PullRequestData{DiffURL: web.URL + "/octocat/Hello-World/pull/1.diff"})
+	assert.True(t, IsNotFound(err))
+
+	_, err = g.PullRequest().RawDiff(&PullRequestData{})
+	assert.Equal(t, ErrURL, err)
+}
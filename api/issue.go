@@ -0,0 +1,262 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IssueArgs specifies args to pass to Get
+type IssueArgs struct {
+	User  string
+	Org   string
+	Repos []string
+
+	// State is one of open, closed or all. Defaults to open
+	State string
+	// Labels only returns issues with every label given
+	Labels []string
+	// Since only returns issues updated at or after this time
+	Since time.Time
+}
+
+func (a *IssueArgs) validate() error {
+	if len(a.User) != 0 && len(a.Org) != 0 {
+		return ErrUserOrg
+	}
+
+	if len(a.User) == 0 && len(a.Org) == 0 {
+		return ErrUserOrg
+	}
+
+	switch a.State {
+	case "", "open", "closed", "all":
+	default:
+		return argUnsupported("State", a.State)
+	}
+
+	return nil
+}
+
+// Issue is an interface for interacting with the issues github api endpoint
+type Issue interface {
+	Get(args *IssueArgs) ([]IssueData, error)
+}
+
+type issue struct {
+	g *ghAPI
+}
+
+// Get will fetch all issues matching the arguments in IssueArgs. github
+// reports pull requests as issues too, see IssueData.IsPullRequest
+func (i *issue) Get(args *IssueArgs) ([]IssueData, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	if len(args.Repos) == 0 {
+		repos, err := i.g.repoNames(&RepoArgs{
+			User: args.User,
+			Org:  args.Org,
+		})
+		if err != nil {
+			return nil, err
+		}
+		args.Repos = repos
+	}
+
+	issues := make([]IssueData, 0)
+	for _, repo := range args.Repos {
+		reqArgs := i.formRequestArgs(args, repo)
+
+		if err := i.g.doFullPagination(reqArgs, extractIssues(&issues)); err != nil {
+			return nil, err
+		}
+	}
+
+	return issues, nil
+}
+
+func (i *issue) formRequestArgs(args *IssueArgs, repo string) *requestArgs {
+	var owner string
+	if len(args.User) != 0 {
+		owner = args.User
+	} else {
+		owner = args.Org
+	}
+
+	values := make(map[string]string)
+	if len(args.State) != 0 {
+		values["state"] = args.State
+	}
+	if len(args.Labels) != 0 {
+		values["labels"] = strings.Join(args.Labels, ",")
+	}
+	if !args.Since.IsZero() {
+		values["since"] = args.Since.UTC().Format(time.RFC3339)
+	}
+
+	return &requestArgs{
+		endpoint: fmt.Sprintf("/repos/%s/%s/issues", owner, repo),
+		method:   "GET",
+		values:   values,
+	}
+}
+
+func extractIssues(issues *[]IssueData) processFunc {
+	return func(resp *http.Response) error {
+		defer resp.Body.Close()
+
+		issueTmp := make([]IssueData, 0)
+
+		decoder := json.NewDecoder(resp.Body)
+
+		if err := decoder.Decode(&issueTmp); err != nil {
+			return err
+		}
+		*issues = append(*issues, issueTmp...)
+
+		return nil
+	}
+}
+
+// IssueReference identifies an issue referenced from a pull request body
+type IssueReference struct {
+	Owner  string `json:"owner"`
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+}
+
+func (r IssueReference) String() string {
+	return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number)
+}
+
+// closingRefRegexp matches the github closing keywords followed by either
+// #123 or owner/repo#123
+var closingRefRegexp = regexp.MustCompile(
+	`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+(?:([\w.-]+)/([\w.-]+))?#(\d+)\b`)
+
+// ParseClosingReferences finds every issue a pull request body says it closes,
+// e.g. "Fixes #123" or "Closes owner/repo#45". References without an explicit
+// repo resolve to owner/repo. Duplicates are removed
+func ParseClosingReferences(body, owner, repo string) []IssueReference {
+	refs := make([]IssueReference, 0)
+	seen := make(map[IssueReference]bool)
+
+	for _, match := range closingRefRegexp.FindAllStringSubmatch(body, -1) {
+		number, err := strconv.Atoi(match[3])
+		if err != nil || number == 0 {
+			continue
+		}
+
+		ref := IssueReference{
+			Owner:  owner,
+			Repo:   repo,
+			Number: number,
+		}
+		if len(match[1]) != 0 {
+			ref.Owner = match[1]
+			ref.Repo = match[2]
+		}
+
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+
+	return refs
+}
+
+// ClosingReferences returns the issues the body of the pull request says it
+// closes. Short references resolve against the base repo
+func (p PullRequestData) ClosingReferences() []IssueReference {
+	var owner, repo string
+	if p.Base.HasRepo() {
+		owner = p.Base.Repo.Owner.Login
+		repo = p.Base.Repo.Name
+	}
+
+	return ParseClosingReferences(p.Body, owner, repo)
+}
+
+// IssueData represents an issue returned by the github api
+type IssueData struct {
+	ID            int            `json:"id"`
+	URL           string         `json:"url"`
+	RepositoryURL string         `json:"repository_url"`
+	LabelsURL     string         `json:"labels_url"`
+	CommentsURL   string         `json:"comments_url"`
+	EventsURL     string         `json:"events_url"`
+	HTMLURL       string         `json:"html_url"`
+	Number        int            `json:"number"`
+	State         string         `json:"state"`
+	Title         string         `json:"title"`
+	Body          string         `json:"body"`
+	User          UserData       `json:"user"`
+	Labels        []LabelData    `json:"labels"`
+	Assignee      *UserData      `json:"assignee"`
+	Assignees     []UserData     `json:"assignees"`
+	Milestone     *MilestoneData `json:"milestone"`
+	Locked        bool           `json:"locked"`
+	Comments      int            `json:"comments"`
+	// PullRequest is only set if this issue is the issue side of a PR
+	PullRequest *IssuePullRequestData `json:"pull_request"`
+	ClosedAt    *time.Time            `json:"closed_at"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// IsPullRequest reports whether the issue is the issue side of a pull request
+func (i IssueData) IsPullRequest() bool {
+	return i.PullRequest != nil
+}
+
+// RepoFullName returns the owner/name of the repo the issue belongs to, taken
+// from its repository URL, or "" if the URL is not set
+func (i IssueData) RepoFullName() string {
+	parts := strings.Split(i.RepositoryURL, "/repos/")
+	if len(parts) != 2 {
+		return ""
+	}
+
+	return parts[1]
+}
+
+// PullRequestRef returns a reference to the pull request the issue represents,
+// and false if it is a plain issue
+func (i IssueData) PullRequestRef() (PullRequestRef, bool) {
+	owner, repo, ok := splitFullName(i.RepoFullName())
+	if !i.IsPullRequest() || !ok {
+		return PullRequestRef{}, false
+	}
+
+	return PullRequestRef{
+		Owner:  owner,
+		Repo:   repo,
+		Number: i.Number,
+	}, true
+}
+
+// IssuePullRequestData links an issue to the pull request it represents
+type IssuePullRequestData struct {
+	URL      string `json:"url"`
+	HTMLURL  string `json:"html_url"`
+	DiffURL  string `json:"diff_url"`
+	PatchURL string `json:"patch_url"`
+}
+
+// LabelData represents a label on an issue or pull request
+type LabelData struct {
+	ID          int    `json:"id"`
+	URL         string `json:"url"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	Default     bool   `json:"default"`
+}
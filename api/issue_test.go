@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseClosingReferences(t *testing.T) {
+	tests := []struct {
+		body string
+		refs []IssueReference
+	}{
+		{"Please pull these awesome changes", []IssueReference{}},
+		{"Fixes #123", []IssueReference{{"octocat", "Hello-World", 123}}},
+		{"closes: #1, resolved #2 and fixed #1", []IssueReference{
+			{"octocat", "Hello-World", 1},
+			{"octocat", "Hello-World", 2},
+		}},
+		{"Closes owner/repo#45\nRefs #46", []IssueReference{{"owner", "repo", 45}}},
+		{"prefixes #7 and unfixes #8", []IssueReference{}},
+	}
+
+	for _, test := range tests {
+		refs := ParseClosingReferences(test.body, "octocat", "Hello-World")
+		assert.Equal(t, test.refs, refs, test.body)
+	}
+}
+
+func TestIssueGet(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/octocat/Hello-World/issues", r.URL.Path)
+		assert.Equal(t, "all", r.URL.Query().Get("state"), "State should be sent as a query value")
+		assert.Equal(t, "bug,ui", r.URL.Query().Get("labels"))
+
+		fmt.Fprint(w, `[
+			{"id": 1, "number": 1347, "state": "open", "title": "Found a bug", "labels": [{"name": "bug"}]},
+			{"id": 2, "number": 1348, "state": "closed", "title": "Fix the bug", "closed_at": "2011-04-22T13:33:48Z",
+			 "pull_request": {"url": "https://api.github.com/repos/octocat/Hello-World/pulls/1348"}}
+		]`)
+	}))
+	defer server.Close()
+
+	issues, err := g.Issues().Get(&IssueArgs{
+		User:   "octocat",
+		Repos:  []string{"Hello-World"},
+		State:  "all",
+		Labels: []string{"bug", "ui"},
+	})
+	assert.NoError(t, err, "Should fetch issues")
+	assert.Equal(t, 2, len(issues))
+	assert.False(t, issues[0].IsPullRequest(), "Plain issue should not be a PR")
+	assert.True(t, issues[1].IsPullRequest(), "Issue with pull_request should be a PR")
+
+	_, err = g.Issues().Get(&IssueArgs{User: "octocat", State: "merged"})
+	assert.Error(t, err, "Unknown state should be rejected")
+}
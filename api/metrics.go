@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doodles526/gogitpr/telemetry"
+)
+
+// apiMetrics records the requests made to github
+type apiMetrics struct {
+	requests        *telemetry.Counter
+	latency         *telemetry.Histogram
+	rateLimitRemain *telemetry.Gauge
+}
+
+func newAPIMetrics(reg *telemetry.Registry) *apiMetrics {
+	if reg == nil {
+		return nil
+	}
+
+	return &apiMetrics{
+		requests: reg.NewCounter("gogitpr_github_requests_total",
+			"Requests made to the github API.", "method", "endpoint", "status"),
+		latency: reg.NewHistogram("gogitpr_github_request_duration_seconds",
+			"Latency of requests made to the github API.", nil, "method", "endpoint"),
+		rateLimitRemain: reg.NewGauge("gogitpr_github_rate_limit_remaining",
+			"Requests left before the github API rate limit resets.", "resource"),
+	}
+}
+
+// observe records a request made to endpoint. resp is nil when the request
+// failed before a response was received. Safe to call on a nil *apiMetrics
+func (m *apiMetrics) observe(method, endpoint string, start time.Time, resp *http.Response) {
+	if m == nil {
+		return
+	}
+
+	endpoint = endpointTemplate(endpoint)
+	m.latency.Observe(time.Since(start).Seconds(), method, endpoint)
+
+	if resp == nil {
+		m.requests.Inc(method, endpoint, "error")
+		return
+	}
+	m.requests.Inc(method, endpoint, strconv.Itoa(resp.StatusCode))
+
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if len(resource) == 0 {
+		resource = "core"
+	}
+	m.rateLimitRemain.Set(float64(remaining), resource)
+}
+
+// templateParams maps a path segment to the name of the segments following it
+// which identify a resource, so endpoints are labelled without every owner,
+// repo and number being a separate series
+var templateParams = map[string][]string{
+	"repos":    {"{owner}", "{repo}"},
+	"users":    {"{user}"},
+	"orgs":     {"{org}"},
+	"teams":    {"{team}"},
+	"commits":  {"{ref}"},
+	"branches": {"{branch}"},
+}
+
+// templateRest maps a path segment to the name given to every segment
+// following it, for resources such as files identified by a path
+var templateRest = map[string]string{
+	"contents": "{path}",
+}
+
+// endpointTemplate returns endpoint with the segments identifying resources
+// replaced by placeholders, e.g. /repos/{owner}/{repo}/pulls/{number}
+func endpointTemplate(endpoint string) string {
+	segments := strings.Split(endpoint, "/")
+
+	for idx := 0; idx < len(segments); idx++ {
+		if param, ok := templateRest[segments[idx]]; ok && idx+1 < len(segments) {
+			segments = append(segments[:idx+1], param)
+			break
+		}
+
+		if params, ok := templateParams[segments[idx]]; ok {
+			for _, param := range params {
+				if idx+1 >= len(segments) {
+					break
+				}
+				idx++
+				segments[idx] = param
+			}
+			continue
+		}
+
+		if _, err := strconv.Atoi(segments[idx]); err == nil {
+			segments[idx] = "{number}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/doodles526/gogitpr/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointTemplate(t *testing.T) {
+	assert.Equal(t, "/repos/{owner}/{repo}/pulls/{number}/reviews", endpointTemplate("/repos/octocat/Hello-World/pulls/1347/reviews"))
+	assert.Equal(t, "/repos/{owner}/{repo}/commits/{ref}/status", endpointTemplate("/repos/octocat/Hello-World/commits/6dcb09b/status"))
+	assert.Equal(t, "/orgs/{org}/teams/{team}/members", endpointTemplate("/orgs/github/teams/justice-league/members"))
+	assert.Equal(t, "/users/{user}/repos", endpointTemplate("/users/octocat/repos"))
+	assert.Equal(t, "/repos/{owner}/{repo}/contents/{path}", endpointTemplate("/repos/octocat/Hello-World/contents/docs/README.md"))
+	assert.Equal(t, "/repos/{owner}/{repo}/branches/{branch}/protection", endpointTemplate("/repos/octocat/Hello-World/branches/main/protection"))
+	assert.Equal(t, "/search/issues", endpointTemplate("/search/issues"))
+	assert.Equal(t, "/repos/{owner}", endpointTemplate("/repos/octocat"), "Short endpoints shouldn't panic")
+}
+
+func TestRequestMetrics(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4998")
+		if r.URL.Path == "/users/ghost" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+			return
+		}
+		fmt.Fprint(w, `{"login": "octocat"}`)
+	}))
+	defer server.Close()
+
+	reg := telemetry.NewRegistry()
+	g.metrics = newAPIMetrics(reg)
+
+	_, err := g.Users().Get("octocat")
+	assert.NoError(t, err)
+	_, err = g.Users().Get("hubot")
+	assert.NoError(t, err)
+	_, err = g.Users().Get("ghost")
+	assert.True(t, IsNotFound(err))
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, reg.Write(buf))
+	assert.Contains(t, buf.String(), `gogitpr_github_requests_total{method="GET",endpoint="/users/{user}",status="200"} 2`)
+	assert.Contains(t, buf.String(), `gogitpr_github_requests_total{method="GET",endpoint="/users/{user}",status="404"} 1`)
+	assert.Contains(t, buf.String(), `gogitpr_github_request_duration_seconds_count{method="GET",endpoint="/users/{user}"} 3`)
+	assert.Contains(t, buf.String(), `gogitpr_github_rate_limit_remaining{resource="core"} 4998`)
+
+	// requests which never get a response are counted as errors
+	server.Close()
+	_, err = g.Users().Get("octocat")
+	assert.Error(t, err)
+
+	buf.Reset()
+	assert.NoError(t, reg.Write(buf))
+	assert.Contains(t, buf.String(), `gogitpr_github_requests_total{method="GET",endpoint="/users/{user}",status="error"} 1`)
+}
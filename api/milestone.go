@@ -1,24 +1,184 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
 	"time"
 )
 
+// MilestoneArgs specifies args to pass to Get
+type MilestoneArgs struct {
+	User  string
+	Org   string
+	Repos []string
+
+	// State is one of open, closed or all. Defaults to open
+	State string
+	// Sort is one of due_on or completeness. Defaults to due_on
+	Sort string
+	// Direction is one of asc or desc. Defaults to asc
+	Direction string
+}
+
+func (a *MilestoneArgs) validate() error {
+	if len(a.User) != 0 && len(a.Org) != 0 {
+		return ErrUserOrg
+	}
+
+	if len(a.User) == 0 && len(a.Org) == 0 {
+		return ErrUserOrg
+	}
+
+	switch a.State {
+	case "", "open", "closed", "all":
+	default:
+		return argUnsupported("State", a.State)
+	}
+
+	switch a.Sort {
+	case "", "due_on", "completeness":
+	default:
+		return argUnsupported("Sort", a.Sort)
+	}
+
+	switch a.Direction {
+	case "", "asc", "desc":
+	default:
+		return argUnsupported("Direction", a.Direction)
+	}
+
+	return nil
+}
+
+// Milestone is an interface for interacting with the milestones github api
+// endpoint
+type Milestone interface {
+	Get(args *MilestoneArgs) ([]MilestoneData, error)
+}
+
+type milestone struct {
+	g *ghAPI
+}
+
+// Get will fetch all milestones matching the arguments in MilestoneArgs,
+// sorted by due date within each repo unless specified otherwise
+func (m *milestone) Get(args *MilestoneArgs) ([]MilestoneData, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	if len(args.Repos) == 0 {
+		repos, err := m.g.repoNames(&RepoArgs{
+			User: args.User,
+			Org:  args.Org,
+		})
+		if err != nil {
+			return nil, err
+		}
+		args.Repos = repos
+	}
+
+	milestones := make([]MilestoneData, 0)
+	for _, repo := range args.Repos {
+		reqArgs := m.formRequestArgs(args, repo)
+
+		if err := m.g.doFullPagination(reqArgs, extractMilestones(&milestones)); err != nil {
+			return nil, err
+		}
+	}
+
+	return milestones, nil
+}
+
+func (m *milestone) formRequestArgs(args *MilestoneArgs, repo string) *requestArgs {
+	var owner string
+	if len(args.User) != 0 {
+		owner = args.User
+	} else {
+		owner = args.Org
+	}
+
+	values := map[string]string{
+		"sort":      "due_on",
+		"direction": "asc",
+	}
+	if len(args.State) != 0 {
+		values["state"] = args.State
+	}
+	if len(args.Sort) != 0 {
+		values["sort"] = args.Sort
+	}
+	if len(args.Direction) != 0 {
+		values["direction"] = args.Direction
+	}
+
+	return &requestArgs{
+		endpoint: fmt.Sprintf("/repos/%s/%s/milestones", owner, repo),
+		method:   "GET",
+		values:   values,
+	}
+}
+
+func extractMilestones(milestones *[]MilestoneData) processFunc {
+	return func(resp *http.Response) error {
+		defer resp.Body.Close()
+
+		milestoneTmp := make([]MilestoneData, 0)
+
+		decoder := json.NewDecoder(resp.Body)
+
+		if err := decoder.Decode(&milestoneTmp); err != nil {
+			return err
+		}
+		*milestones = append(*milestones, milestoneTmp...)
+
+		return nil
+	}
+}
+
 // MilestoneData represents a milestone of a repo/PR
 type MilestoneData struct {
-	URL          string    `json:"url"`
-	HTMLURL      string    `json:"html_url"`
-	LabelsURL    string    `json:"labels_url"`
-	ID           int       `json:"id"`
-	Number       int       `json:"number"`
-	State        string    `json:"state"`
-	Title        string    `json:"title"`
-	Description  string    `json:"description"`
-	Creator      UserData  `json:"creator"`
-	OpenIssues   int       `json:"open_issues"`
-	ClosedIssues int       `json:"closed_issues"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	ClosedAt     time.Time `json:"closed_at"`
-	DueOn        time.Time `json:"due_on"`
+	URL          string     `json:"url"`
+	HTMLURL      string     `json:"html_url"`
+	LabelsURL    string     `json:"labels_url"`
+	ID           int        `json:"id"`
+	Number       int        `json:"number"`
+	State        string     `json:"state"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	Creator      UserData   `json:"creator"`
+	OpenIssues   int        `json:"open_issues"`
+	ClosedIssues int        `json:"closed_issues"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	DueOn        *time.Time `json:"due_on"`
+}
+
+// HasDueDate reports whether a due date has been set on the milestone
+func (m MilestoneData) HasDueDate() bool {
+	return m.DueOn != nil
+}
+
+// IsOverdue reports whether the milestone is still open past its due date
+func (m MilestoneData) IsOverdue(now time.Time) bool {
+	return m.State == "open" && m.HasDueDate() && now.After(*m.DueOn)
+}
+
+// RepoFullName returns the owner/name of the repo the milestone belongs to,
+// taken from its api URL, or "" if the URL is not set
+func (m MilestoneData) RepoFullName() string {
+	parts := strings.Split(m.URL, "/repos/")
+	if len(parts) != 2 {
+		return ""
+	}
+
+	path := strings.SplitN(parts[1], "/", 3)
+	if len(path) < 2 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s", path[0], path[1])
 }
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMilestoneGet(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/octocat/Hello-World/milestones", r.URL.Path)
+		assert.Equal(t, "closed", r.URL.Query().Get("state"))
+		assert.Equal(t, "due_on", r.URL.Query().Get("sort"), "Should sort by due date by default")
+		assert.Equal(t, "asc", r.URL.Query().Get("direction"))
+
+		fmt.Fprint(w, `[
+			{"id": 1002604, "number": 1, "state": "closed", "title": "v1.0", "due_on": "2012-10-09T23:39:01Z",
+			 "url": "https://api.github.com/repos/octocat/Hello-World/milestones/1"},
+			{"id": 1002605, "number": 2, "state": "closed", "title": "someday", "due_on": null}
+		]`)
+	}))
+	defer server.Close()
+
+	milestones, err := g.Milestones().Get(&MilestoneArgs{
+		Org:   "octocat",
+		Repos: []string{"Hello-World"},
+		State: "closed",
+	})
+	assert.NoError(t, err, "Should fetch milestones")
+	assert.Equal(t, 2, len(milestones))
+	assert.True(t, milestones[0].HasDueDate())
+	assert.False(t, milestones[1].HasDueDate())
+	assert.Equal(t, "octocat/Hello-World", milestones[0].RepoFullName())
+	assert.Equal(t, "", milestones[1].RepoFullName())
+
+	_, err = g.Milestones().Get(&MilestoneArgs{Org: "octocat", Sort: "title"})
+	assert.Error(t, err, "Unknown sort should be rejected")
+}
+
+func TestMilestoneIsOverdue(t *testing.T) {
+	var pr PullRequestData
+	assert.NoError(t, json.Unmarshal([]byte(prTestFixture), &pr))
+
+	m := *pr.Milestone
+	before := m.DueOn.Add(-time.Hour)
+	after := m.DueOn.Add(time.Hour)
+
+	assert.False(t, m.IsOverdue(before), "Should not be overdue before the due date")
+	assert.True(t, m.IsOverdue(after), "Open milestone should be overdue after the due date")
+
+	m.State = "closed"
+	assert.False(t, m.IsOverdue(after), "Closed milestone should never be overdue")
+
+	m.State = "open"
+	m.DueOn = nil
+	assert.False(t, m.IsOverdue(after), "Milestone without a due date should never be overdue")
+}
@@ -373,3 +373,64 @@ var prTestFixture = `{
       "site_admin": false
     }
   }`
+
+// prNullTestFixture is an open, unassigned PR with no milestone, opened from a
+// fork which has since been deleted
+var prNullTestFixture = `{
+    "id": 2,
+    "url": "https://api.github.com/repos/octocat/Hello-World/pulls/1348",
+    "html_url": "https://github.com/octocat/Hello-World/pull/1348",
+    "diff_url": "https://github.com/octocat/Hello-World/pull/1348.diff",
+    "patch_url": "https://github.com/octocat/Hello-World/pull/1348.patch",
+    "issue_url": "https://api.github.com/repos/octocat/Hello-World/issues/1348",
+    "statuses_url": "https://api.github.com/repos/octocat/Hello-World/statuses/e5bd3914e2e596debea16f433f57875b5b90bcd6",
+    "number": 1348,
+    "state": "open",
+    "title": "orphaned-feature",
+    "body": null,
+    "assignee": null,
+    "milestone": null,
+    "locked": false,
+    "created_at": "2011-01-26T19:01:12Z",
+    "updated_at": "2011-01-26T19:01:12Z",
+    "closed_at": null,
+    "merged_at": null,
+    "head": {
+      "label": "ghost:orphaned-feature",
+      "ref": "orphaned-feature",
+      "sha": "e5bd3914e2e596debea16f433f57875b5b90bcd6",
+      "user": {
+        "login": "ghost",
+        "id": 10137,
+        "type": "User",
+        "site_admin": false
+      },
+      "repo": null
+    },
+    "base": {
+      "label": "octocat:master",
+      "ref": "master",
+      "sha": "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+      "user": {
+        "login": "octocat",
+        "id": 1,
+        "type": "User",
+        "site_admin": false
+      },
+      "repo": {
+        "id": 1296269,
+        "name": "Hello-World",
+        "full_name": "octocat/Hello-World",
+        "default_branch": "master",
+        "pushed_at": null,
+        "created_at": "2011-01-26T19:01:12Z",
+        "updated_at": "2011-01-26T19:14:43Z"
+      }
+    },
+    "user": {
+      "login": "ghost",
+      "id": 10137,
+      "type": "User",
+      "site_admin": false
+    }
+  }`
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/peterhellberg/link" //RFC5988 complient header parser
+)
+
+// maxPerPage is the largest page size github serves
+const maxPerPage = 100
+
+// errStopPagination may be returned by a processFunc to have doFullPagination
+// stop without error once it has every item it needs
+var errStopPagination = errors.New("stop pagination")
+
+// Pagination is how the pages of a list after the first are fetched
+type Pagination int
+
+const (
+	// PaginationDefault requests pages by number, as lists always were
+	PaginationDefault Pagination = iota
+	// PaginationFollowNext requests the next link of each page verbatim
+	// until a page has none. It works with cursors and endpoints which give
+	// no last link, and can't skip pages when items shift between them
+	PaginationFollowNext
+	// PaginationPageNumbers requests every page by number, up to the page of
+	// the last link of the first page
+	PaginationPageNumbers
+)
+
+func (p Pagination) String() string {
+	switch p {
+	case PaginationFollowNext:
+		return "next links"
+	case PaginationDefault, PaginationPageNumbers:
+		return "page numbers"
+	default:
+		return fmt.Sprintf("Pagination(%d)", int(p))
+	}
+}
+
+func (p Pagination) validate() error {
+	switch p {
+	case PaginationDefault, PaginationFollowNext, PaginationPageNumbers:
+		return nil
+	default:
+		return argUnsupported("Pagination", p)
+	}
+}
+
+// paginationFor is the pagination of endpoint
+func (g *ghAPI) paginationFor(endpoint string) Pagination {
+	if pagination, ok := g.endpointPagination[endpointTemplate(endpoint)]; ok && pagination != PaginationDefault {
+		return pagination
+	}
+
+	if g.pagination == PaginationDefault {
+		return PaginationPageNumbers
+	}
+
+	return g.pagination
+}
+
+// doFullPagination requests every page of args, passing each response to f
+// in order, until f returns errStopPagination
+func (g *ghAPI) doFullPagination(args *requestArgs, f processFunc) error {
+	if err := g.paginate(args, f); err != errStopPagination {
+		return err
+	}
+
+	return nil
+}
+
+func (g *ghAPI) paginate(args *requestArgs, f processFunc) error {
+	nArgs := deepCopyRequestArgs(args)
+
+	if nArgs.values == nil {
+		nArgs.values = make(map[string]string)
+	}
+	if _, ok := nArgs.values["per_page"]; !ok && g.perPage != 0 {
+		nArgs.values["per_page"] = strconv.Itoa(g.perPage)
+	}
+
+	pagination := g.paginationFor(nArgs.endpoint)
+	g.logger.Debugf("paginating %s by %s", nArgs.endpoint, pagination)
+
+	resp, err := g.doRequest(nArgs)
+	if err != nil {
+		return err
+	}
+
+	// We have to process this now since passing it to f() could alter the header
+	// and we need to ensure someone isn't able to alter the Link header before this
+	links := link.ParseHeader(resp.Header)
+
+	if err := f(resp); err != nil {
+		return err
+	}
+
+	if pagination == PaginationFollowNext {
+		return g.followNext(nArgs, links, f)
+	}
+
+	return g.paginateByNumber(nArgs, links, f)
+}
+
+// paginateByNumber requests pages 2 up to the page of the last link
+func (g *ghAPI) paginateByNumber(args *requestArgs, links link.Group, f processFunc) error {
+	totalPages := 1
+
+	if last, ok := links["last"]; ok {
+		linkURL, err := url.Parse(last.URI)
+		if err != nil {
+			return err
+		}
+		if pageOfLast := linkURL.Query().Get("page"); pageOfLast != "" {
+			iPageOfLast, err := strconv.Atoi(pageOfLast)
+			if err != nil {
+				return err
+			}
+			totalPages = iPageOfLast
+		}
+	}
+
+	for curPage := 2; curPage <= totalPages; curPage++ {
+		args.values["page"] = strconv.Itoa(curPage)
+
+		if _, err := g.doPage(args, f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// followNext requests the next link of each page until a page has none
+func (g *ghAPI) followNext(args *requestArgs, links link.Group, f processFunc) error {
+	seen := make(map[string]bool)
+
+	for {
+		next, ok := links["next"]
+		if !ok {
+			return nil
+		}
+
+		nextURL, err := url.Parse(next.URI)
+		if err != nil {
+			return err
+		}
+		nextURL = g.baseURL.ResolveReference(nextURL)
+
+		// the request carries our token, so must stay with github
+		if nextURL.Host != g.baseURL.Host {
+			return fmt.Errorf("refusing to follow next link of %s to %s", args.endpoint, nextURL.Host)
+		}
+		if seen[nextURL.String()] {
+			return fmt.Errorf("next links of %s loop at %s", args.endpoint, nextURL)
+		}
+		seen[nextURL.String()] = true
+
+		pageArgs := deepCopyRequestArgs(args)
+		pageArgs.url = nextURL.String()
+
+		if links, err = g.doPage(pageArgs, f); err != nil {
+			return err
+		}
+	}
+}
+
+// doPage requests a page of args, returning its links once f has processed it
+func (g *ghAPI) doPage(args *requestArgs, f processFunc) (link.Group, error) {
+	resp, err := g.doRequest(args)
+	if err != nil {
+		return nil, err
+	}
+
+	links := link.ParseHeader(resp.Header)
+
+	return links, f(resp)
+}
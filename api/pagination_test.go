@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cursorHandler serves 3 pages of items with cursor next links, and no last
+// link, as github's cursor paginated endpoints do
+func cursorHandler(perPage *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*perPage = r.URL.Query().Get("per_page")
+
+		cursor := r.URL.Query().Get("after")
+		next := map[string]string{"": "Y3Vyc29yOjI=", "Y3Vyc29yOjI=": "Y3Vyc29yOjM="}[cursor]
+		if len(next) != 0 {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?after=%s&per_page=%s>; rel="next"`, r.Host, r.URL.Path, next, *perPage))
+		}
+		fmt.Fprintf(w, `[{"login": "member-%s"}]`, cursor)
+	}
+}
+
+func TestFollowNextPagination(t *testing.T) {
+	var perPage string
+	g, server := newTestAPI(cursorHandler(&perPage))
+	defer server.Close()
+	g.pagination = PaginationFollowNext
+	g.perPage = 50
+
+	members, err := g.Users().OrgMembers("octocat")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"member-", "member-Y3Vyc29yOjI=", "member-Y3Vyc29yOjM="}, logins(members), "Cursors should be followed")
+	assert.Equal(t, "50", perPage)
+
+	g.endpointPagination = map[string]Pagination{"/orgs/{org}/members": PaginationPageNumbers}
+	members, err = g.Users().OrgMembers("octocat")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(members), "Without a last link page numbers can't paginate")
+}
+
+func TestPageNumberPagination(t *testing.T) {
+	g, server := newFakeAPI()
+	defer server.Close()
+	g.pagination = PaginationPageNumbers
+	g.perPage = 2
+
+	members := make([]string, 0, 5)
+	for i := 1; i <= 5; i++ {
+		members = append(members, fmt.Sprintf(`{"login": "member-%d"}`, i))
+	}
+	server.HandleList("/orgs/octocat/members", members...)
+
+	users, err := g.Users().OrgMembers("octocat")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"member-1", "member-2", "member-3", "member-4", "member-5"}, logins(users))
+	assert.Equal(t, 3, server.Requests("GET", "/orgs/octocat/members"))
+}
+
+func TestFollowNextPaginationSafety(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/orgs/evil/members" {
+			w.Header().Set("Link", `<https://evil.example.com/members?page=2>; rel="next"`)
+		} else {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, r.URL.Path))
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+	g.pagination = PaginationFollowNext
+
+	_, err := g.Users().OrgMembers("evil")
+	assert.EqualError(t, err, "refusing to follow next link of /orgs/evil/members to evil.example.com")
+
+	_, err = g.Users().OrgMembers("loop")
+	assert.Error(t, err, "Looping next links should fail rather than never end")
+}
+
+func TestPaginationFor(t *testing.T) {
+	g := &ghAPI{}
+	assert.Equal(t, PaginationPageNumbers, g.paginationFor("/repos/octocat/Hello-World/pulls"), "Pages should be requested by number by default")
+
+	g.pagination = PaginationFollowNext
+	g.endpointPagination = map[string]Pagination{"/search/issues": PaginationPageNumbers}
+	assert.Equal(t, PaginationFollowNext, g.paginationFor("/repos/octocat/Hello-World/pulls"))
+	assert.Equal(t, PaginationPageNumbers, g.paginationFor("/search/issues"))
+
+	_, err := NewGithubAPI(&GithubAPIArgs{ApplicationName: "test", PerPage: 101})
+	assert.Error(t, err, "github serves at most 100 per page")
+
+	_, err = NewGithubAPI(&GithubAPIArgs{ApplicationName: "test", EndpointPagination: map[string]Pagination{"/search/issues": 9}})
+	assert.Error(t, err)
+}
+
+func logins(users []UserData) []string {
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.Login)
+	}
+	return names
+}
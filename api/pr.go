@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -12,6 +13,13 @@ type PullRequestArgs struct {
 	User  string
 	Org   string
 	Repos []string
+
+	// RepoFilter restricts which repos are synced when Repos is empty. Its
+	// User and Org are taken from the PullRequestArgs
+	RepoFilter *RepoArgs
+
+	// State is one of "open", "closed" or "all". Github defaults to "open"
+	State string
 }
 
 func (a *PullRequestArgs) validate() error {
@@ -23,12 +31,172 @@ func (a *PullRequestArgs) validate() error {
 		return ErrUserOrg
 	}
 
-	return nil
+	switch a.State {
+	case "", "open", "closed", "all":
+		return nil
+	default:
+		return argUnsupported("State", a.State)
+	}
 }
 
 // PullRequest is an interface for interacting with the PullRequest github api endpoint
 type PullRequest interface {
 	Get(args *PullRequestArgs) ([]PullRequestData, error)
+	GetByRef(ref *PullRequestRef) (*PullRequestData, error)
+	GetByRefs(refs []PullRequestRef) ([]PullRequestData, error)
+
+	Create(args *CreatePullRequestArgs) (*PullRequestData, error)
+	Update(args *UpdatePullRequestArgs) (*PullRequestData, error)
+	Close(ref *PullRequestRef) (*PullRequestData, error)
+	Merge(args *MergePullRequestArgs) (*MergeResultData, error)
+	RequestReviewers(args *RequestReviewersArgs) (*PullRequestData, error)
+
+	Reviews(ref *PullRequestRef) ([]ReviewData, error)
+
+	Diff(ref *PullRequestRef) (string, error)
+	Patch(ref *PullRequestRef) (string, error)
+	RawDiff(pr *PullRequestData) (string, error)
+	RawPatch(pr *PullRequestData) (string, error)
+}
+
+// PullRequestRef identifies a single pull request
+type PullRequestRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+func (r *PullRequestRef) validate() error {
+	if r == nil || len(r.Owner) == 0 || len(r.Repo) == 0 {
+		return ErrOwnerRepo
+	}
+
+	if r.Number <= 0 {
+		return ErrNumber
+	}
+
+	return nil
+}
+
+func (r *PullRequestRef) endpoint() string {
+	return fmt.Sprintf("/repos/%s/%s/pulls/%d", r.Owner, r.Repo, r.Number)
+}
+
+// CreatePullRequestArgs specifies args to pass to Create
+type CreatePullRequestArgs struct {
+	Owner string `json:"-"`
+	Repo  string `json:"-"`
+
+	Title string `json:"title"`
+	// Head is the branch to merge, prefixed with "user:" for a fork
+	Head                string `json:"head"`
+	Base                string `json:"base"`
+	Body                string `json:"body,omitempty"`
+	Draft               bool   `json:"draft,omitempty"`
+	MaintainerCanModify *bool  `json:"maintainer_can_modify,omitempty"`
+}
+
+func (a *CreatePullRequestArgs) validate() error {
+	if len(a.Owner) == 0 || len(a.Repo) == 0 {
+		return ErrOwnerRepo
+	}
+
+	if len(a.Title) == 0 || len(a.Head) == 0 || len(a.Base) == 0 {
+		return ErrCreatePullRequest
+	}
+
+	return nil
+}
+
+// UpdatePullRequestArgs specifies args to pass to Update. Only fields which
+// are set are changed
+type UpdatePullRequestArgs struct {
+	PullRequestRef `json:"-"`
+
+	Title *string `json:"title,omitempty"`
+	Body  *string `json:"body,omitempty"`
+	Base  *string `json:"base,omitempty"`
+	// State is either "open" or "closed"
+	State *string `json:"state,omitempty"`
+}
+
+func (a *UpdatePullRequestArgs) validate() error {
+	if err := a.PullRequestRef.validate(); err != nil {
+		return err
+	}
+
+	if a.State != nil && *a.State != "open" && *a.State != "closed" {
+		return argUnsupported("State", *a.State)
+	}
+
+	return nil
+}
+
+// MergeMethod specifies how a pull request is merged
+type MergeMethod string
+
+const (
+	// MergeMethodMerge creates a merge commit
+	MergeMethodMerge MergeMethod = "merge"
+	// MergeMethodSquash squashes all commits into one
+	MergeMethodSquash MergeMethod = "squash"
+	// MergeMethodRebase rebases all commits onto the base branch
+	MergeMethodRebase MergeMethod = "rebase"
+)
+
+// MergePullRequestArgs specifies args to pass to Merge
+type MergePullRequestArgs struct {
+	PullRequestRef `json:"-"`
+
+	CommitTitle   string `json:"commit_title,omitempty"`
+	CommitMessage string `json:"commit_message,omitempty"`
+	// Sha, if set, must match the head of the pull request for the merge to
+	// go through
+	Sha string `json:"sha,omitempty"`
+	// MergeMethod defaults to MergeMethodMerge
+	MergeMethod MergeMethod `json:"merge_method,omitempty"`
+}
+
+func (a *MergePullRequestArgs) validate() error {
+	if err := a.PullRequestRef.validate(); err != nil {
+		return err
+	}
+
+	switch a.MergeMethod {
+	case "", MergeMethodMerge, MergeMethodSquash, MergeMethodRebase:
+		return nil
+	default:
+		return argUnsupported("MergeMethod", a.MergeMethod)
+	}
+}
+
+// MergeResultData is returned by github upon a successful merge
+type MergeResultData struct {
+	Sha     string `json:"sha"`
+	Merged  bool   `json:"merged"`
+	Message string `json:"message"`
+}
+
+// RequestReviewersArgs specifies args to pass to RequestReviewers
+type RequestReviewersArgs struct {
+	PullRequestRef `json:"-"`
+
+	// Reviewers are user logins
+	Reviewers []string `json:"reviewers,omitempty"`
+	// TeamReviewers are team slugs
+	TeamReviewers []string `json:"team_reviewers,omitempty"`
+}
+
+func (a *RequestReviewersArgs) validate() error {
+	if err := a.PullRequestRef.validate(); err != nil {
+		return err
+	}
+
+	if len(a.Reviewers) == 0 && len(a.TeamReviewers) == 0 {
+		return ErrNoReviewers
+	}
+
+	return nil
 }
 
 type pullRequest struct {
@@ -54,6 +222,9 @@ func (p *pullRequest) Get(args *PullRequestArgs) ([]PullRequestData, error) {
 	pullRequests := make([]PullRequestData, 0)
 	for _, repo := range args.Repos {
 		reqArgs := p.formRequestArgs(args.User, args.Org, repo)
+		if len(args.State) != 0 {
+			reqArgs.values["state"] = args.State
+		}
 
 		if err := p.g.doFullPagination(reqArgs, extractPRs(&pullRequests)); err != nil {
 			return nil, err
@@ -63,6 +234,304 @@ func (p *pullRequest) Get(args *PullRequestArgs) ([]PullRequestData, error) {
 	return pullRequests, nil
 }
 
+// GetByRef fetches a single pull request
+func (p *pullRequest) GetByRef(ref *PullRequestRef) (*PullRequestData, error) {
+	if err := ref.validate(); err != nil {
+		return nil, err
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: ref.endpoint(),
+		method:   "GET",
+	}
+
+	pr := new(PullRequestData)
+	if err := p.g.doJSON(reqArgs, pr); err != nil {
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+// GetByRefs fetches the pull requests refs refer to, returned in the same
+// order. Rather than a request per pull request, the pulls of each repo with
+// several refs are listed most recently updated first until all its refs are
+// found, listing no more pages than the repo has refs. Any not found are then
+// fetched individually. Listed pull requests lack the fields only GetByRef
+// returns, such as their size and mergeability
+func (p *pullRequest) GetByRefs(refs []PullRequestRef) ([]PullRequestData, error) {
+	byRepo := make(map[string][]PullRequestRef)
+	repos := make([]string, 0)
+	for idx := range refs {
+		if err := refs[idx].validate(); err != nil {
+			return nil, err
+		}
+
+		repo := fmt.Sprintf("%s/%s", refs[idx].Owner, refs[idx].Repo)
+		if _, ok := byRepo[repo]; !ok {
+			repos = append(repos, repo)
+		}
+		byRepo[repo] = append(byRepo[repo], refs[idx])
+	}
+
+	found := make(map[PullRequestRef]PullRequestData)
+	for _, repo := range repos {
+		if err := p.listRefs(byRepo[repo], found); err != nil {
+			return nil, err
+		}
+	}
+
+	prs := make([]PullRequestData, 0, len(refs))
+	for idx := range refs {
+		pr, ok := found[refs[idx]]
+		if !ok {
+			full, err := p.GetByRef(&refs[idx])
+			if err != nil {
+				return nil, err
+			}
+			pr = *full
+			found[refs[idx]] = pr
+		}
+		prs = append(prs, pr)
+	}
+
+	return prs, nil
+}
+
+// listRefs lists the pulls of the repo of refs into found, keyed by ref. A
+// single ref is cheaper fetched alone, so is left to the caller
+func (p *pullRequest) listRefs(refs []PullRequestRef, found map[PullRequestRef]PullRequestData) error {
+	if len(refs) < 2 {
+		return nil
+	}
+
+	wanted := make(map[int]PullRequestRef)
+	for _, ref := range refs {
+		wanted[ref.Number] = ref
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: fmt.Sprintf("/repos/%s/%s/pulls", refs[0].Owner, refs[0].Repo),
+		method:   "GET",
+		values: map[string]string{
+			"state":     "all",
+			"sort":      "updated",
+			"direction": "desc",
+			"per_page":  strconv.Itoa(maxPerPage),
+		},
+	}
+
+	pages := 0
+	return p.g.doFullPagination(reqArgs, func(resp *http.Response) error {
+		pages++
+
+		page := make([]PullRequestData, 0)
+		if err := extractPRs(&page)(resp); err != nil {
+			return err
+		}
+
+		for _, pr := range page {
+			if ref, ok := wanted[pr.Number]; ok {
+				found[ref] = pr
+				delete(wanted, pr.Number)
+			}
+		}
+
+		if len(wanted) == 0 || pages >= len(refs) {
+			return errStopPagination
+		}
+
+		return nil
+	})
+}
+
+// Create opens a new pull request
+func (p *pullRequest) Create(args *CreatePullRequestArgs) (*PullRequestData, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: fmt.Sprintf("/repos/%s/%s/pulls", args.Owner, args.Repo),
+		method:   "POST",
+		body:     args,
+	}
+
+	pr := new(PullRequestData)
+	if err := p.g.doJSON(reqArgs, pr); err != nil {
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+// Update changes the title, body, base or state of a pull request
+func (p *pullRequest) Update(args *UpdatePullRequestArgs) (*PullRequestData, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: args.endpoint(),
+		method:   "PATCH",
+		body:     args,
+	}
+
+	pr := new(PullRequestData)
+	if err := p.g.doJSON(reqArgs, pr); err != nil {
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+// Close closes a pull request without merging it
+func (p *pullRequest) Close(ref *PullRequestRef) (*PullRequestData, error) {
+	if err := ref.validate(); err != nil {
+		return nil, err
+	}
+
+	closed := "closed"
+
+	return p.Update(&UpdatePullRequestArgs{
+		PullRequestRef: *ref,
+		State:          &closed,
+	})
+}
+
+// Merge merges a pull request. github responds 405 if the pull request is not
+// mergeable and 409 if Sha does not match the head, both returned as a
+// ResponseError
+func (p *pullRequest) Merge(args *MergePullRequestArgs) (*MergeResultData, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: fmt.Sprintf("%s/merge", args.endpoint()),
+		method:   "PUT",
+		body:     args,
+	}
+
+	result := new(MergeResultData)
+	if err := p.g.doJSON(reqArgs, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RequestReviewers requests reviews on a pull request from users and teams
+func (p *pullRequest) RequestReviewers(args *RequestReviewersArgs) (*PullRequestData, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: fmt.Sprintf("%s/requested_reviewers", args.endpoint()),
+		method:   "POST",
+		body:     args,
+	}
+
+	pr := new(PullRequestData)
+	if err := p.g.doJSON(reqArgs, pr); err != nil {
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+// Reviews fetches every review of a single pull request, in the order they
+// were submitted
+func (p *pullRequest) Reviews(ref *PullRequestRef) ([]ReviewData, error) {
+	if err := ref.validate(); err != nil {
+		return nil, err
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: fmt.Sprintf("%s/reviews", ref.endpoint()),
+		method:   "GET",
+	}
+
+	reviews := make([]ReviewData, 0)
+	if err := p.g.doFullPagination(reqArgs, extractReviews(&reviews)); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// Diff fetches the unified diff of a single pull request from the API, see
+// diff.Parse. Unlike RawDiff, it works for private repos
+func (p *pullRequest) Diff(ref *PullRequestRef) (string, error) {
+	return p.text(ref, MediaTypeDiff)
+}
+
+// Patch fetches a single pull request from the API as a series of patches,
+// one per commit
+func (p *pullRequest) Patch(ref *PullRequestRef) (string, error) {
+	return p.text(ref, MediaTypePatch)
+}
+
+// text fetches a single pull request as text of mediaType
+func (p *pullRequest) text(ref *PullRequestRef, mediaType string) (string, error) {
+	if err := ref.validate(); err != nil {
+		return "", err
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: ref.endpoint(),
+		method:   "GET",
+		accept:   mediaType,
+	}
+
+	return p.g.doText(reqArgs)
+}
+
+// RawDiff fetches the unified diff of pr from its DiffURL. The URL is on
+// github's website rather than the API, so no token is sent with it and only
+// public repos' diffs can be fetched. See Diff
+func (p *pullRequest) RawDiff(pr *PullRequestData) (string, error) {
+	return p.raw(pr.DiffURL, "/{owner}/{repo}/pull/{number}.diff")
+}
+
+// RawPatch fetches pr as a series of patches, one per commit, from its
+// PatchURL. The URL is on github's website rather than the API, so no token is
+// sent with it
+func (p *pullRequest) RawPatch(pr *PullRequestData) (string, error) {
+	return p.raw(pr.PatchURL, "/{owner}/{repo}/pull/{number}.patch")
+}
+
+// raw fetches u as text, naming the request endpoint
+func (p *pullRequest) raw(u, endpoint string) (string, error) {
+	if len(u) == 0 {
+		return "", ErrURL
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: endpoint,
+		method:   "GET",
+		url:      u,
+		accept:   "text/plain",
+	}
+
+	return p.g.doText(reqArgs)
+}
+
+func extractReviews(reviews *[]ReviewData) processFunc {
+	return func(resp *http.Response) error {
+		defer resp.Body.Close()
+
+		reviewsTmp := make([]ReviewData, 0)
+		if err := json.NewDecoder(resp.Body).Decode(&reviewsTmp); err != nil {
+			return err
+		}
+		*reviews = append(*reviews, reviewsTmp...)
+
+		return nil
+	}
+}
+
 func extractPRs(prData *[]PullRequestData) processFunc {
 	return func(resp *http.Response) error {
 		defer resp.Body.Close()
@@ -90,64 +559,70 @@ func (p *pullRequest) formRequestArgs(user, org, repo string) *requestArgs {
 	endpoint := fmt.Sprintf("/repos/%s/%s/pulls", owner, repo)
 
 	return &requestArgs{
+		values:   make(map[string]string),
 		endpoint: endpoint,
 		method:   "GET",
 	}
 }
 
 func (p *pullRequest) populateRepos(args *PullRequestArgs) error {
-	var repoArgs *RepoArgs
-	if len(args.User) != 0 {
-		repoArgs = &RepoArgs{
-			User: args.User,
-		}
-	} else if len(args.Org) != 0 {
-		repoArgs = &RepoArgs{
-			Org: args.Org,
-		}
-	} else {
-		repoArgs = &RepoArgs{}
+	repoArgs := new(RepoArgs)
+	if args.RepoFilter != nil {
+		*repoArgs = *args.RepoFilter
 	}
+	repoArgs.User = args.User
+	repoArgs.Org = args.Org
 
-	repos, err := p.g.Repos().Get(repoArgs)
+	repos, err := p.g.repoNames(repoArgs)
 	if err != nil {
 		return err
 	}
 
-	for _, repo := range repos {
-		args.Repos = append(args.Repos, repo.Name)
-	}
+	args.Repos = append(args.Repos, repos...)
 
 	return nil
 }
 
 // PullRequestData auto-generated from https://mholt.github.io/json-to-go/
 type PullRequestData struct {
-	ID                int           `json:"id"`
-	URL               string        `json:"url"`
-	HTMLURL           string        `json:"html_url"`
-	DiffURL           string        `json:"diff_url"`
-	PatchURL          string        `json:"patch_url"`
-	IssueURL          string        `json:"issue_url"`
-	CommitsURL        string        `json:"commits_url"`
-	ReviewCommentsURL string        `json:"review_comments_url"`
-	ReviewCommentURL  string        `json:"review_comment_url"`
-	CommentsURL       string        `json:"comments_url"`
-	StatusesURL       string        `json:"statuses_url"`
-	Number            int           `json:"number"`
-	State             string        `json:"state"`
-	Title             string        `json:"title"`
-	Body              string        `json:"body"`
-	Assignee          UserData      `json:"assignee"`
-	Milestone         MilestoneData `json:"milestone"`
-	Locked            bool          `json:"locked"`
-	CreatedAt         time.Time     `json:"created_at"`
-	UpdatedAt         time.Time     `json:"updated_at"`
-	ClosedAt          time.Time     `json:"closed_at"`
-	MergedAt          time.Time     `json:"merged_at"`
-	Head              CommitData    `json:"head"`
-	Base              CommitData    `json:"base"`
-	Links             struct {
+	ID                int            `json:"id"`
+	URL               string         `json:"url"`
+	HTMLURL           string         `json:"html_url"`
+	DiffURL           string         `json:"diff_url"`
+	PatchURL          string         `json:"patch_url"`
+	IssueURL          string         `json:"issue_url"`
+	CommitsURL        string         `json:"commits_url"`
+	ReviewCommentsURL string         `json:"review_comments_url"`
+	ReviewCommentURL  string         `json:"review_comment_url"`
+	CommentsURL       string         `json:"comments_url"`
+	StatusesURL       string         `json:"statuses_url"`
+	Number            int            `json:"number"`
+	State             string         `json:"state"`
+	Title             string         `json:"title"`
+	Body              string         `json:"body"`
+	Labels            []LabelData    `json:"labels"`
+	Assignee          *UserData      `json:"assignee"`
+	Milestone         *MilestoneData `json:"milestone"`
+	Locked            bool           `json:"locked"`
+	Draft             bool           `json:"draft"`
+	Comments          int            `json:"comments"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	ClosedAt          *time.Time     `json:"closed_at"`
+	MergedAt          *time.Time     `json:"merged_at"`
+	Head              CommitData     `json:"head"`
+	Base              CommitData     `json:"base"`
+	// Additions, Deletions, ChangedFiles and Commits are only set when a
+	// single pull request is fetched, not when they are listed
+	Additions    int `json:"additions"`
+	Deletions    int `json:"deletions"`
+	ChangedFiles int `json:"changed_files"`
+	Commits      int `json:"commits"`
+	// Mergeable is also only set when a single pull request is fetched, and
+	// stays nil while github is still computing it
+	Mergeable      *bool  `json:"mergeable"`
+	MergeableState string `json:"mergeable_state"`
+	Links          struct {
 		Self struct {
 			Href string `json:"href"`
 		} `json:"self"`
@@ -173,5 +648,86 @@ type PullRequestData struct {
 			Href string `json:"href"`
 		} `json:"statuses"`
 	} `json:"_links"`
-	User UserData `json:"user"`
+	User               UserData   `json:"user"`
+	RequestedReviewers []UserData `json:"requested_reviewers"`
+	RequestedTeams     []TeamData `json:"requested_teams"`
+}
+
+// IsMerged reports whether the pull request has been merged
+func (p PullRequestData) IsMerged() bool {
+	return p.MergedAt != nil
+}
+
+// IsClosed reports whether the pull request has been closed, merged or not
+func (p PullRequestData) IsClosed() bool {
+	return p.ClosedAt != nil
+}
+
+// HasMilestone reports whether the pull request is attached to a milestone
+func (p PullRequestData) HasMilestone() bool {
+	return p.Milestone != nil
+}
+
+// HasAssignee reports whether the pull request has an assignee
+func (p PullRequestData) HasAssignee() bool {
+	return p.Assignee != nil
+}
+
+// AssigneeLogin returns the login of the assignee, or "" if unassigned
+func (p PullRequestData) AssigneeLogin() string {
+	if p.Assignee == nil {
+		return ""
+	}
+
+	return p.Assignee.Login
+}
+
+// MilestoneTitle returns the title of the milestone, or "" if there is none
+func (p PullRequestData) MilestoneTitle() string {
+	if p.Milestone == nil {
+		return ""
+	}
+
+	return p.Milestone.Title
+}
+
+// HasSize reports whether Additions, Deletions and ChangedFiles are known,
+// which they are only when the pull request was fetched on its own
+func (p PullRequestData) HasSize() bool {
+	return p.Commits > 0 || p.ChangedFiles > 0
+}
+
+// HasConflicts reports whether github found the pull request to conflict with
+// its base. It is false while that is unknown
+func (p PullRequestData) HasConflicts() bool {
+	if p.MergeableState == "dirty" {
+		return true
+	}
+
+	return p.Mergeable != nil && !*p.Mergeable
+}
+
+// HasRequestedReviewers reports whether any user or team has been requested
+// to review the pull request
+func (p PullRequestData) HasRequestedReviewers() bool {
+	return len(p.RequestedReviewers) != 0 || len(p.RequestedTeams) != 0
+}
+
+// LinesChanged returns the number of lines added and deleted
+func (p PullRequestData) LinesChanged() int {
+	return p.Additions + p.Deletions
+}
+
+// PullRequestRef returns the ref of the pull request in its base repo, or
+// false if the base repo is unknown
+func (p PullRequestData) PullRequestRef() (PullRequestRef, bool) {
+	if !p.Base.HasRepo() {
+		return PullRequestRef{}, false
+	}
+
+	return PullRequestRef{
+		Owner:  p.Base.Repo.Owner.Login,
+		Repo:   p.Base.Repo.Name,
+		Number: p.Number,
+	}, true
 }
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullRequestDataDecode(t *testing.T) {
+	var pr PullRequestData
+	err := json.Unmarshal([]byte(prTestFixture), &pr)
+	assert.NoError(t, err, "Should decode PR fixture")
+
+	assert.True(t, pr.IsMerged(), "PR fixture should be merged")
+	assert.True(t, pr.IsClosed(), "PR fixture should be closed")
+	assert.True(t, pr.HasMilestone(), "PR fixture should have a milestone")
+	assert.True(t, pr.HasAssignee(), "PR fixture should have an assignee")
+	assert.Equal(t, "v1.0", pr.MilestoneTitle())
+	assert.Equal(t, "octocat", pr.AssigneeLogin())
+	assert.True(t, pr.Milestone.HasDueDate(), "Milestone should have a due date")
+	assert.True(t, pr.Head.HasRepo(), "Head repo should exist")
+	assert.Equal(t, "octocat/Hello-World", pr.Head.Repo.FullName)
+}
+
+func TestPullRequestDataDecodeNulls(t *testing.T) {
+	var pr PullRequestData
+	err := json.Unmarshal([]byte(prNullTestFixture), &pr)
+	assert.NoError(t, err, "Should decode PR fixture with nulls")
+
+	assert.False(t, pr.IsMerged(), "null merged_at should not be merged")
+	assert.False(t, pr.IsClosed(), "null closed_at should not be closed")
+	assert.False(t, pr.HasMilestone(), "null milestone should not be set")
+	assert.False(t, pr.HasAssignee(), "null assignee should not be set")
+	assert.Equal(t, "", pr.MilestoneTitle())
+	assert.Equal(t, "", pr.AssigneeLogin())
+	assert.False(t, pr.Head.HasRepo(), "Deleted fork should have no head repo")
+	assert.True(t, pr.Base.HasRepo(), "Base repo should exist")
+	assert.Nil(t, pr.Base.Repo.PushedAt, "null pushed_at should stay nil")
+}
+
+func TestPullRequestWrites(t *testing.T) {
+	type call struct {
+		method string
+		path   string
+		body   map[string]interface{}
+	}
+	var calls []call
+
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body := make(map[string]interface{})
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body), "Request body should be JSON")
+		calls = append(calls, call{r.Method, r.URL.Path, body})
+
+		if strings.HasSuffix(r.URL.Path, "/merge") {
+			fmt.Fprint(w, `{"sha": "6dcb09b5b57875f334f61aebed695e2e4193db5e", "merged": true, "message": "Pull Request successfully merged"}`)
+			return
+		}
+		fmt.Fprint(w, prTestFixture)
+	}))
+	defer server.Close()
+
+	pr, err := g.PullRequest().Create(&CreatePullRequestArgs{
+		Owner: "octocat",
+		Repo:  "Hello-World",
+		Title: "new-feature",
+		Head:  "octocat:new-topic",
+		Base:  "master",
+	})
+	assert.NoError(t, err, "Should create PR")
+	assert.Equal(t, 1347, pr.Number)
+
+	ref := PullRequestRef{Owner: "octocat", Repo: "Hello-World", Number: 1347}
+	title := "renamed"
+	_, err = g.PullRequest().Update(&UpdatePullRequestArgs{PullRequestRef: ref, Title: &title})
+	assert.NoError(t, err, "Should update PR")
+
+	_, err = g.PullRequest().Close(&ref)
+	assert.NoError(t, err, "Should close PR")
+
+	result, err := g.PullRequest().Merge(&MergePullRequestArgs{
+		PullRequestRef: ref,
+		MergeMethod:    MergeMethodSquash,
+		Sha:            "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+	})
+	assert.NoError(t, err, "Should merge PR")
+	assert.True(t, result.Merged)
+
+	_, err = g.PullRequest().RequestReviewers(&RequestReviewersArgs{PullRequestRef: ref, TeamReviewers: []string{"justice-league"}})
+	assert.NoError(t, err, "Should request reviewers")
+
+	assert.Equal(t, 5, len(calls))
+	assert.Equal(t, call{"POST", "/repos/octocat/Hello-World/pulls", map[string]interface{}{
+		"title": "new-feature", "head": "octocat:new-topic", "base": "master"}}, calls[0])
+	assert.Equal(t, call{"PATCH", "/repos/octocat/Hello-World/pulls/1347", map[string]interface{}{
+		"title": "renamed"}}, calls[1], "Only set fields should be sent")
+	assert.Equal(t, call{"PATCH", "/repos/octocat/Hello-World/pulls/1347", map[string]interface{}{
+		"state": "closed"}}, calls[2])
+	assert.Equal(t, call{"PUT", "/repos/octocat/Hello-World/pulls/1347/merge", map[string]interface{}{
+		"merge_method": "squash", "sha": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}}, calls[3])
+	assert.Equal(t, call{"POST", "/repos/octocat/Hello-World/pulls/1347/requested_reviewers", map[string]interface{}{
+		"team_reviewers": []interface{}{"justice-league"}}}, calls[4])
+}
+
+func TestPullRequestWriteValidation(t *testing.T) {
+	p := &pullRequest{}
+	ref := PullRequestRef{Owner: "octocat", Repo: "Hello-World", Number: 1347}
+
+	_, err := p.Create(&CreatePullRequestArgs{Owner: "octocat", Repo: "Hello-World", Title: "x"})
+	assert.Equal(t, ErrCreatePullRequest, err)
+
+	_, err = p.Close(&PullRequestRef{Owner: "octocat", Repo: "Hello-World"})
+	assert.Equal(t, ErrNumber, err)
+
+	_, err = p.Close(nil)
+	assert.Equal(t, ErrOwnerRepo, err, "A nil ref should be rejected rather than panic")
+
+	_, err = p.GetByRef(nil)
+	assert.Equal(t, ErrOwnerRepo, err)
+
+	_, err = p.Merge(&MergePullRequestArgs{PullRequestRef: ref, MergeMethod: "octopus"})
+	assert.Error(t, err, "Unknown merge method should be rejected")
+
+	_, err = p.RequestReviewers(&RequestReviewersArgs{PullRequestRef: ref})
+	assert.Equal(t, ErrNoReviewers, err)
+}
+
+func TestPullRequestGetState(t *testing.T) {
+	var states []string
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		states = append(states, r.URL.Query().Get("state"))
+		fmt.Fprintf(w, "[%s]", prTestFixture)
+	}))
+	defer server.Close()
+
+	prs, err := g.PullRequest().Get(&PullRequestArgs{Org: "octocat", Repos: []string{"Hello-World"}, State: "all"})
+	assert.NoError(t, err, "Should list PRs")
+	assert.Equal(t, 1, len(prs))
+	assert.Equal(t, []string{"all"}, states)
+
+	_, err = g.PullRequest().Get(&PullRequestArgs{Org: "octocat", Repos: []string{"Hello-World"}, State: "merged"})
+	assert.Error(t, err, "Unknown state should be rejected")
+}
+
+func TestPullRequestGetFakeServer(t *testing.T) {
+	g, server := newFakeAPI()
+	defer server.Close()
+	server.HandleList("/repos/octocat/Hello-World/pulls", prTestFixture, prNullTestFixture)
+
+	prs, err := g.PullRequest().Get(&PullRequestArgs{Org: "octocat", Repos: []string{"Hello-World"}})
+	assert.NoError(t, err, "Should list PRs")
+	assert.Equal(t, 2, len(prs))
+	assert.Equal(t, "octocat", prs[0].Assignee.Login)
+	assert.Nil(t, prs[1].Milestone)
+
+	_, err = g.PullRequest().Get(&PullRequestArgs{Org: "octocat", Repos: []string{"Spoon-Knife"}})
+	assert.True(t, IsNotFound(err), "Unknown repos should be reported as not found")
+}
+
+func TestPullRequestGetByRefs(t *testing.T) {
+	g, server := newFakeAPI()
+	defer server.Close()
+
+	items := make([]string, 0)
+	for number := 1; number <= 150; number++ {
+		items = append(items, fmt.Sprintf(`{"id": %d, "number": %d}`, 1000+number, number))
+	}
+	server.HandleList("/repos/octocat/Hello-World/pulls", items...)
+	server.HandleObject("GET", "/repos/octocat/Hello-World/pulls/500", http.StatusOK, `{"id": 1500, "number": 500, "additions": 10}`)
+	server.HandleObject("GET", "/repos/octocat/Spoon-Knife/pulls/7", http.StatusOK, `{"id": 7, "number": 7}`)
+
+	hello := func(number int) PullRequestRef {
+		return PullRequestRef{Owner: "octocat", Repo: "Hello-World", Number: number}
+	}
+
+	prs, err := g.PullRequest().GetByRefs([]PullRequestRef{
+		hello(120), {Owner: "octocat", Repo: "Spoon-Knife", Number: 7}, hello(500), hello(2),
+	})
+	assert.NoError(t, err)
+	numbers := make([]int, 0)
+	for _, pr := range prs {
+		numbers = append(numbers, pr.Number)
+	}
+	assert.Equal(t, []int{120, 7, 500, 2}, numbers, "PRs should be returned in the order of their refs")
+	assert.Equal(t, 10, prs[2].Additions, "PRs not listed should be fetched individually")
+	assert.Equal(t, 2, server.Requests("GET", "/repos/octocat/Hello-World/pulls"), "Every page should be listed")
+	assert.Equal(t, 1, server.Requests("GET", "/repos/octocat/Hello-World/pulls/500"))
+	assert.Equal(t, 0, server.Requests("GET", "/repos/octocat/Hello-World/pulls/120"), "Listed PRs shouldn't be fetched again")
+	assert.Equal(t, 1, server.Requests("GET", "/repos/octocat/Spoon-Knife/pulls/7"), "A single PR of a repo should be fetched alone")
+
+	_, err = g.PullRequest().GetByRefs([]PullRequestRef{hello(1), hello(2)})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, server.Requests("GET", "/repos/octocat/Hello-World/pulls"), "Listing should stop once every ref is found")
+
+	_, err = g.PullRequest().GetByRefs([]PullRequestRef{hello(1), {Owner: "octocat"}})
+	assert.Equal(t, ErrOwnerRepo, err)
+}
+
+func TestPullRequestReviews(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/octocat/Hello-World/pulls/1347/reviews", r.URL.Path)
+		fmt.Fprint(w, `[
+			{"id": 80, "user": {"login": "hubot"}, "state": "COMMENTED", "submitted_at": "2011-01-26T19:01:12Z"},
+			{"id": 81, "user": {"login": "octocat"}, "state": "APPROVED", "submitted_at": "2011-01-27T19:01:12Z"}
+		]`)
+	}))
+	defer server.Close()
+
+	reviews, err := g.PullRequest().Reviews(&PullRequestRef{Owner: "octocat", Repo: "Hello-World", Number: 1347})
+	assert.NoError(t, err, "Should list reviews")
+	assert.Equal(t, 2, len(reviews))
+	assert.False(t, reviews[0].IsApproval())
+	assert.True(t, reviews[1].IsApproval())
+	assert.True(t, reviews[0].SubmittedBefore(reviews[1]))
+
+	_, err = g.PullRequest().Reviews(&PullRequestRef{Owner: "octocat", Repo: "Hello-World"})
+	assert.Equal(t, ErrNumber, err)
+}
+
+func TestPullRequestDataSize(t *testing.T) {
+	var pr PullRequestData
+	assert.NoError(t, json.Unmarshal([]byte(`{"additions": 100, "deletions": 3, "changed_files": 5, "commits": 3}`), &pr))
+	assert.True(t, pr.HasSize())
+	assert.Equal(t, 103, pr.LinesChanged())
+
+	var listed PullRequestData
+	assert.NoError(t, json.Unmarshal([]byte(prTestFixture), &listed))
+	assert.False(t, listed.HasSize(), "Listed PRs don't carry their size")
+}
+
+func TestPullRequestDataConflicts(t *testing.T) {
+	var pr PullRequestData
+	assert.NoError(t, json.Unmarshal([]byte(`{"mergeable": null, "mergeable_state": "unknown"}`), &pr))
+	assert.False(t, pr.HasConflicts(), "Unknown mergeability shouldn't be a conflict")
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"mergeable": false, "mergeable_state": "dirty"}`), &pr))
+	assert.True(t, pr.HasConflicts())
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"mergeable": true, "mergeable_state": "clean", "requested_teams": [{"slug": "justice-league"}]}`), &pr))
+	assert.False(t, pr.HasConflicts())
+	assert.True(t, pr.HasRequestedReviewers(), "Requested teams count as reviewers")
+}
+
+func TestPullRequestDiff(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/octocat/Hello-World/pulls/1347", r.URL.Path)
+		fmt.Fprint(w, r.Header.Get("Accept"))
+	}))
+	defer server.Close()
+
+	ref := &PullRequestRef{Owner: "octocat", Repo: "Hello-World", Number: 1347}
+
+	diff, err := g.PullRequest().Diff(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, MediaTypeDiff, diff, "Diffs should be requested by media type")
+
+	patch, err := g.PullRequest().Patch(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, MediaTypePatch, patch)
+
+	_, err = g.PullRequest().Diff(&PullRequestRef{Owner: "octocat", Repo: "Hello-World"})
+	assert.Equal(t, ErrNumber, err)
+}
@@ -4,12 +4,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path"
+	"regexp"
+	"strings"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 // Repo is an interface for interacting with the repository endpoint of the github api
 type Repo interface {
 	Get(args *RepoArgs) ([]RepoData, error)
+	CodeOwners(repo *RepoData) (string, error)
+	Contents(owner, repo, path, ref string) (*ContentData, error)
+	Branches(owner, repo string) ([]BranchData, error)
+	BranchProtection(owner, repo, branch string) (*BranchProtectionData, error)
+	Languages(owner, repo string) (map[string]int, error)
 }
 
 type repo struct {
@@ -20,15 +30,74 @@ type repo struct {
 type RepoArgs struct {
 	User string
 	Org  string
+
+	// Type is one of all, owner, public, private, forks, sources or member.
+	// owner is only valid for users, public, private, forks and sources only
+	// for orgs
+	Type string
+	// Sort is one of created, updated, pushed or full_name
+	Sort string
+	// Direction is one of asc or desc
+	Direction string
+
+	SkipArchived bool
+	SkipForks    bool
+
+	// Topics only keeps repos which have every topic listed
+	Topics []string
+
+	// Include and Exclude are patterns matched against the repo name, or the
+	// full owner/name if the pattern contains a "/". Patterns are globs, or
+	// regular expressions when wrapped in slashes, e.g. "/^api-.*$/". When
+	// Include is empty every repo is included, and Exclude always wins
+	Include []string
+	Exclude []string
+
+	include []*repoPattern
+	exclude []*repoPattern
 }
 
 func (r *RepoArgs) validate() error {
-	if len(r.User) != 0 && len(r.Org) == 0 {
+	if len(r.User) != 0 && len(r.Org) != 0 {
 		return ErrUserOrg
 	} else if len(r.User) == 0 && len(r.Org) == 0 {
 		return ErrUserOrg
 	}
 
+	switch r.Type {
+	case "", "all", "member":
+	case "owner":
+		if len(r.User) == 0 {
+			return argUnsupported("Type", r.Type)
+		}
+	case "public", "private", "forks", "sources":
+		if len(r.Org) == 0 {
+			return argUnsupported("Type", r.Type)
+		}
+	default:
+		return argUnsupported("Type", r.Type)
+	}
+
+	switch r.Sort {
+	case "", "created", "updated", "pushed", "full_name":
+	default:
+		return argUnsupported("Sort", r.Sort)
+	}
+
+	switch r.Direction {
+	case "", "asc", "desc":
+	default:
+		return argUnsupported("Direction", r.Direction)
+	}
+
+	var err error
+	if r.include, err = compileRepoPatterns(r.Include); err != nil {
+		return err
+	}
+	if r.exclude, err = compileRepoPatterns(r.Exclude); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -39,27 +108,194 @@ func (r *repo) Get(args *RepoArgs) ([]RepoData, error) {
 	}
 
 	repos := make([]RepoData, 0)
-	reqArgs := r.formRequestArgs(args.User, args.Org)
+	reqArgs := r.formRequestArgs(args)
 
 	if err := r.g.doFullPagination(reqArgs, extractRepos(&repos)); err != nil {
 		return nil, err
 	}
 
-	return repos, nil
+	return args.filter(repos), nil
 }
 
-func (r *repo) formRequestArgs(user, org string) *requestArgs {
+// Languages fetches the languages of owner/repo, mapped to the bytes of code
+// written in each
+func (r *repo) Languages(owner, repo string) (map[string]int, error) {
+	if len(owner) == 0 || len(repo) == 0 {
+		return nil, ErrOwnerRepo
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: fmt.Sprintf("/repos/%s/%s/languages", owner, repo),
+		method:   "GET",
+	}
+
+	languages := make(map[string]int)
+	if err := r.g.doJSON(reqArgs, &languages); err != nil {
+		return nil, err
+	}
+
+	return languages, nil
+}
+
+// codeOwnersPaths are where github looks for a CODEOWNERS file, in order
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners fetches the CODEOWNERS file of repo's default branch, from the
+// first of the locations github looks in which has one. ErrNoCodeOwners is
+// returned if none do
+func (r *repo) CodeOwners(repo *RepoData) (string, error) {
+	if len(repo.Owner.Login) == 0 || len(repo.Name) == 0 {
+		return "", ErrOwnerRepo
+	}
+
+	for _, path := range codeOwnersPaths {
+		content, err := r.Contents(repo.Owner.Login, repo.Name, path, repo.DefaultBranch)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if content.Type != "file" {
+			// e.g. a directory named CODEOWNERS, which github ignores too
+			continue
+		}
+
+		return content.Content, nil
+	}
+
+	return "", ErrNoCodeOwners
+}
+
+func (r *repo) formRequestArgs(args *RepoArgs) *requestArgs {
 	var endpoint string
-	if len(user) != 0 {
-		endpoint = fmt.Sprintf("/users/%s/repos", user)
+	if len(args.User) != 0 {
+		endpoint = fmt.Sprintf("/users/%s/repos", args.User)
 	} else {
-		endpoint = fmt.Sprintf("/orgs/%s/repos", org)
+		endpoint = fmt.Sprintf("/orgs/%s/repos", args.Org)
+	}
+
+	values := make(map[string]string)
+	if len(args.Type) != 0 {
+		values["type"] = args.Type
+	}
+	if len(args.Sort) != 0 {
+		values["sort"] = args.Sort
+	}
+	if len(args.Direction) != 0 {
+		values["direction"] = args.Direction
 	}
 
 	return &requestArgs{
 		endpoint: endpoint,
 		method:   "GET",
+		values:   values,
+	}
+}
+
+// Filter drops the repos which don't match args, as Get does, for repos
+// fetched without them. The order of repos is kept
+func (r *RepoArgs) Filter(repos []RepoData) ([]RepoData, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	return r.filter(repos), nil
+}
+
+// filter drops the repos which don't match args. The order of repos is kept
+func (r *RepoArgs) filter(repos []RepoData) []RepoData {
+	filtered := make([]RepoData, 0, len(repos))
+	for _, repo := range repos {
+		if r.matches(repo) {
+			filtered = append(filtered, repo)
+		}
+	}
+
+	return filtered
+}
+
+func (r *RepoArgs) matches(repo RepoData) bool {
+	if r.SkipArchived && repo.Archived {
+		return false
+	}
+
+	if r.SkipForks && repo.Fork {
+		return false
+	}
+
+	for _, topic := range r.Topics {
+		if !repo.HasTopic(topic) {
+			return false
+		}
+	}
+
+	for _, p := range r.exclude {
+		if p.matches(repo) {
+			return false
+		}
+	}
+
+	if len(r.include) == 0 {
+		return true
 	}
+
+	for _, p := range r.include {
+		if p.matches(repo) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// repoPattern is a compiled RepoArgs Include or Exclude pattern
+type repoPattern struct {
+	glob     string
+	re       *regexp.Regexp
+	fullName bool
+}
+
+func compileRepoPatterns(patterns []string) ([]*repoPattern, error) {
+	compiled := make([]*repoPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		p := &repoPattern{
+			fullName: strings.Contains(pattern, "/"),
+		}
+
+		if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid repo pattern %q", pattern)
+			}
+			p.re = re
+			p.fullName = strings.Contains(re.String(), "/")
+		} else {
+			// path.Match only reports malformed patterns when matching
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, errors.Wrapf(err, "invalid repo pattern %q", pattern)
+			}
+			p.glob = pattern
+		}
+
+		compiled = append(compiled, p)
+	}
+
+	return compiled, nil
+}
+
+func (p *repoPattern) matches(repo RepoData) bool {
+	name := repo.Name
+	if p.fullName {
+		name = repo.FullName
+	}
+
+	if p.re != nil {
+		return p.re.MatchString(name)
+	}
+
+	ok, _ := path.Match(p.glob, name)
+	return ok
 }
 
 func extractRepos(repos *[]RepoData) processFunc {
@@ -145,7 +381,7 @@ type RepoData struct {
 	HasPages         bool        `json:"has_pages"`
 	HasDownloads     bool        `json:"has_downloads"`
 	Archived         bool        `json:"archived"`
-	PushedAt         time.Time   `json:"pushed_at"`
+	PushedAt         *time.Time  `json:"pushed_at"`
 	CreatedAt        time.Time   `json:"created_at"`
 	UpdatedAt        time.Time   `json:"updated_at"`
 	Permissions      struct {
@@ -159,3 +395,24 @@ type RepoData struct {
 	SubscribersCount int  `json:"subscribers_count"`
 	NetworkCount     int  `json:"network_count"`
 }
+
+// splitFullName splits an owner/name repo name into its parts
+func splitFullName(fullName string) (string, string, bool) {
+	parts := strings.Split(fullName, "/")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// HasTopic reports whether the repo is tagged with topic
+func (r RepoData) HasTopic(topic string) bool {
+	for _, t := range r.Topics {
+		if strings.EqualFold(t, topic) {
+			return true
+		}
+	}
+
+	return false
+}
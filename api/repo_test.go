@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoArgsValidate(t *testing.T) {
+	assert.NoError(t, (&RepoArgs{User: "octocat"}).validate(), "User only should be valid")
+	assert.NoError(t, (&RepoArgs{Org: "github"}).validate(), "Org only should be valid")
+	assert.Equal(t, ErrUserOrg, (&RepoArgs{User: "octocat", Org: "github"}).validate())
+	assert.Equal(t, ErrUserOrg, (&RepoArgs{}).validate())
+
+	assert.NoError(t, (&RepoArgs{User: "octocat", Type: "owner"}).validate())
+	assert.Error(t, (&RepoArgs{Org: "github", Type: "owner"}).validate(), "owner is only valid for users")
+	assert.Error(t, (&RepoArgs{User: "octocat", Type: "forks"}).validate(), "forks is only valid for orgs")
+	assert.Error(t, (&RepoArgs{Org: "github", Sort: "stars"}).validate())
+	assert.Error(t, (&RepoArgs{Org: "github", Include: []string{"/[/"}}).validate(), "Bad regexp should be rejected")
+	assert.Error(t, (&RepoArgs{Org: "github", Exclude: []string{"[a-"}}).validate(), "Bad glob should be rejected")
+}
+
+func TestRepoArgsFilter(t *testing.T) {
+	repos := []RepoData{
+		{Name: "api-server", FullName: "github/api-server", Topics: []string{"go", "API"}},
+		{Name: "api-client", FullName: "github/api-client", Fork: true, Topics: []string{"go"}},
+		{Name: "legacy-api", FullName: "github/legacy-api", Archived: true, Topics: []string{"api"}},
+		{Name: "docs", FullName: "github/docs"},
+	}
+
+	names := func(args *RepoArgs) []string {
+		assert.NoError(t, args.validate())
+		names := make([]string, 0)
+		for _, repo := range args.filter(repos) {
+			names = append(names, repo.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"api-server", "api-client", "legacy-api", "docs"}, names(&RepoArgs{Org: "github"}))
+	assert.Equal(t, []string{"api-server", "docs"}, names(&RepoArgs{Org: "github", SkipArchived: true, SkipForks: true}))
+	assert.Equal(t, []string{"api-server", "legacy-api"}, names(&RepoArgs{Org: "github", Topics: []string{"api"}}))
+	assert.Equal(t, []string{"api-server", "api-client"}, names(&RepoArgs{Org: "github", Include: []string{"api-*"}}))
+	assert.Equal(t, []string{"api-server", "legacy-api"}, names(&RepoArgs{Org: "github", Include: []string{"/-(server|api)$/"}}))
+	assert.Equal(t, []string{"api-client", "legacy-api", "docs"}, names(&RepoArgs{Org: "github", Exclude: []string{"github/*-server"}}))
+	assert.Equal(t, []string{"api-client"}, names(&RepoArgs{Org: "github", Include: []string{"*api*"}, Exclude: []string{"api-server", "/^legacy/"}}))
+}
+
+func TestRepoGetUser(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/users/octocat/repos", r.URL.Path)
+		assert.Equal(t, "owner", r.URL.Query().Get("type"))
+		assert.Equal(t, "pushed", r.URL.Query().Get("sort"))
+
+		fmt.Fprint(w, `[{"name": "Hello-World", "full_name": "octocat/Hello-World"}, {"name": "Spoon-Knife", "fork": true}]`)
+	}))
+	defer server.Close()
+
+	repos, err := g.Repos().Get(&RepoArgs{User: "octocat", Type: "owner", Sort: "pushed", SkipForks: true})
+	assert.NoError(t, err, "Should fetch a user's repos")
+	assert.Equal(t, 1, len(repos))
+	assert.Equal(t, "Hello-World", repos[0].Name)
+}
+
+func TestRepoLanguages(t *testing.T) {
+	g, server := newFakeAPI()
+	defer server.Close()
+
+	server.HandleObject("GET", "/repos/octocat/Hello-World/languages", http.StatusOK, `{"C": 78769, "Python": 7769}`)
+
+	languages, err := g.Repos().Languages("octocat", "Hello-World")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"C": 78769, "Python": 7769}, languages)
+
+	_, err = g.Repos().Languages("octocat", "")
+	assert.Equal(t, ErrOwnerRepo, err)
+}
+
+func TestRepoCodeOwners(t *testing.T) {
+	g, server := newFakeAPI()
+	defer server.Close()
+
+	server.Handle("GET", "/repos/octocat/Hello-World/contents/CODEOWNERS", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "main", r.URL.Query().Get("ref"), "The default branch should be read")
+		fmt.Fprint(w, `{"type": "file", "encoding": "base64", "path": "CODEOWNERS", "content": "KiBAb2N0b2NhdAo=\n"}`)
+	}))
+	server.HandleObject("GET", "/repos/octocat/Spoon-Knife/contents/CODEOWNERS", http.StatusOK, `[{"type": "file", "path": "CODEOWNERS/README"}]`)
+
+	repo := &RepoData{Name: "Hello-World", Owner: UserData{Login: "octocat"}, DefaultBranch: "main"}
+	text, err := g.Repos().CodeOwners(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, "* @octocat\n", text)
+	assert.Equal(t, 1, server.Requests("GET", "/repos/octocat/Hello-World/contents/.github/CODEOWNERS"), "The .github directory should be checked first")
+
+	_, err = g.Repos().CodeOwners(&RepoData{Name: "Spoon-Knife", Owner: UserData{Login: "octocat"}})
+	assert.Equal(t, ErrNoCodeOwners, err)
+	assert.Equal(t, 1, server.Requests("GET", "/repos/octocat/Spoon-Knife/contents/docs/CODEOWNERS"))
+
+	_, err = g.Repos().CodeOwners(&RepoData{Name: "Spoon-Knife"})
+	assert.Equal(t, ErrOwnerRepo, err)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy decides which failed requests are retried, and how long to wait
+// between attempts. Only idempotent methods are retried: GET, HEAD, OPTIONS,
+// PUT and DELETE
+type RetryPolicy struct {
+	// MaxAttempts is the most a request is attempted, including the first.
+	// One or less disables retries
+	MaxAttempts int
+	// BaseDelay is waited before the first retry, doubling before each
+	// retry after it up to MaxDelay. Each delay is randomly reduced by up to
+	// half so clients failing together don't retry together
+	BaseDelay time.Duration
+	// MaxDelay also bounds the Retry-After github may ask for. A request
+	// asked to wait longer isn't retried, and its error is returned
+	MaxDelay time.Duration
+	// RetryableStatusCodes are the responses retried. Requests which fail
+	// without a response, e.g. on a connection reset, are always retried
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy retries the gateway errors github returns while it is
+// briefly unavailable
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:          3,
+	BaseDelay:            time.Second,
+	MaxDelay:             30 * time.Second,
+	RetryableStatusCodes: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+}
+
+func (p *RetryPolicy) validate() error {
+	if p.MaxAttempts <= 1 {
+		return nil
+	}
+
+	if p.BaseDelay <= 0 {
+		return fmt.Errorf("BaseDelay must be positive in RetryPolicy")
+	}
+
+	if p.MaxDelay < p.BaseDelay {
+		return fmt.Errorf("MaxDelay must be at least BaseDelay in RetryPolicy")
+	}
+
+	return nil
+}
+
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// retrier applies a RetryPolicy. A nil *retrier never retries
+type retrier struct {
+	policy RetryPolicy
+	sleep  func(context.Context, time.Duration) error
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newRetrier(policy *RetryPolicy) *retrier {
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return nil
+	}
+
+	return &retrier{
+		policy: *policy,
+		sleep:  sleepContext,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// retry reports whether a request of method should be attempted again after
+// attempt, which got resp or failed with err, and if so how long to wait
+func (r *retrier) retry(method string, attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if r == nil || attempt >= r.policy.MaxAttempts || !idempotentMethods[method] {
+		return 0, false
+	}
+
+	if err == nil && !r.retryableStatus(resp.StatusCode) {
+		return 0, false
+	}
+
+	delay := r.delay(attempt)
+	if resp != nil {
+		// github says how long to wait when it's throttling us. Retrying any
+		// sooner only gets throttled again, so give up rather than wait
+		// longer than MaxDelay
+		if after, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			wait := time.Duration(after) * time.Second
+			if wait > r.policy.MaxDelay {
+				return 0, false
+			}
+			if wait > delay {
+				delay = wait
+			}
+		}
+	}
+
+	return delay, true
+}
+
+func (r *retrier) retryableStatus(code int) bool {
+	for _, retryable := range r.policy.RetryableStatusCodes {
+		if code == retryable {
+			return true
+		}
+	}
+
+	return false
+}
+
+// delay returns the backoff after attempt, with jitter
+func (r *retrier) delay(attempt int) time.Duration {
+	delay := r.policy.BaseDelay
+	for n := 1; n < attempt && delay < r.policy.MaxDelay; n++ {
+		delay *= 2
+	}
+	if delay > r.policy.MaxDelay {
+		delay = r.policy.MaxDelay
+	}
+
+	half := int64(delay / 2)
+	if half == 0 {
+		return delay
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return time.Duration(half + r.rand.Int63n(half+1))
+}
+
+// sleepContext waits for d, returning early with the error of ctx once it's
+// done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
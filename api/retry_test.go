@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingHandler fails the first failures requests to each path with status,
+// or by dropping the connection when status is 0
+func failingHandler(t *testing.T, failures, status int, handler http.HandlerFunc) (http.Handler, func(path string) int) {
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			attempts[r.URL.Path]++
+			n := attempts[r.URL.Path]
+			mu.Unlock()
+
+			if n > failures {
+				handler(w, r)
+				return
+			}
+
+			if status == 0 {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				assert.NoError(t, err)
+				conn.Close()
+				return
+			}
+
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"message": "Server Error"}`)
+		}), func(path string) int {
+			mu.Lock()
+			defer mu.Unlock()
+			return attempts[path]
+		}
+}
+
+func newRetryTestAPI(handler http.Handler) (*ghAPI, *[]time.Duration, func()) {
+	g, server := newTestAPI(handler)
+
+	policy := DefaultRetryPolicy
+	g.retrier = newRetrier(&policy)
+
+	delays := make([]time.Duration, 0)
+	g.retrier.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	return g, &delays, server.Close
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login": "octocat"}`)
+	}
+
+	for _, status := range []int{http.StatusBadGateway, 0} {
+		handler, attempts := failingHandler(t, 2, status, ok)
+		g, delays, done := newRetryTestAPI(handler)
+
+		u, err := g.Users().Get("octocat")
+		assert.NoError(t, err, "Should succeed on the third attempt")
+		assert.Equal(t, "octocat", u.Login)
+		assert.Equal(t, 3, attempts("/users/octocat"))
+		assert.Equal(t, 2, len(*delays))
+
+		done()
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	handler, attempts := failingHandler(t, 10, http.StatusServiceUnavailable, nil)
+	g, _, done := newRetryTestAPI(handler)
+	defer done()
+
+	_, err := g.Users().Get("octocat")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, err.(*ResponseError).StatusCode)
+	assert.Equal(t, DefaultRetryPolicy.MaxAttempts, attempts("/users/octocat"))
+}
+
+func TestRetryOnlyRetryable(t *testing.T) {
+	handler, attempts := failingHandler(t, 10, http.StatusBadGateway, nil)
+	g, _, done := newRetryTestAPI(handler)
+	defer done()
+
+	_, err := g.PullRequest().Create(&CreatePullRequestArgs{
+		Owner: "octocat", Repo: "Hello-World", Title: "new-feature", Head: "new-topic", Base: "master",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts("/repos/octocat/Hello-World/pulls"), "POST isn't idempotent so shouldn't be retried")
+
+	handler, attempts = failingHandler(t, 10, http.StatusNotFound, nil)
+	g, _, done = newRetryTestAPI(handler)
+	defer done()
+
+	_, err = g.Users().Get("ghost")
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, 1, attempts("/users/ghost"), "404s aren't transient so shouldn't be retried")
+}
+
+func TestRetryPagination(t *testing.T) {
+	handler, attempts := failingHandler(t, 1, http.StatusBadGateway, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/orgs/octocat/members?page=2>; rel="last"`, r.Host))
+		}
+		fmt.Fprintf(w, `[{"login": "page%s"}]`, r.URL.Query().Get("page"))
+	})
+	g, _, done := newRetryTestAPI(handler)
+	defer done()
+
+	members, err := g.Users().OrgMembers("octocat")
+	assert.NoError(t, err, "A transient failure shouldn't abort pagination")
+	assert.Equal(t, 2, len(members))
+	assert.Equal(t, 3, attempts("/orgs/octocat/members"))
+}
+
+func TestRetryDelay(t *testing.T) {
+	r := newRetrier(&RetryPolicy{
+		MaxAttempts:          10,
+		BaseDelay:            time.Second,
+		MaxDelay:             5 * time.Second,
+		RetryableStatusCodes: []int{http.StatusBadGateway},
+	})
+
+	for attempt, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 8: 5 * time.Second} {
+		d := r.delay(attempt)
+		assert.True(t, d >= max/2 && d <= max, "Delay after attempt %d should be jittered within [%s, %s], got %s", attempt, max/2, max, d)
+	}
+
+	resp := &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{"Retry-After": {"4"}}}
+	d, ok := r.retry(http.MethodGet, 1, resp, nil)
+	assert.True(t, ok)
+	assert.Equal(t, 4*time.Second, d, "Retry-After should be honoured")
+
+	resp.Header.Set("Retry-After", "5")
+	d, ok = r.retry(http.MethodGet, 1, resp, nil)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, d, "Retry-After up to MaxDelay should be honoured in full")
+
+	resp.Header.Set("Retry-After", "3600")
+	_, ok = r.retry(http.MethodGet, 1, resp, nil)
+	assert.False(t, ok, "Retry-After past MaxDelay should give up rather than retry early")
+
+	_, ok = r.retry(http.MethodGet, 10, resp, nil)
+	assert.False(t, ok, "Attempts past MaxAttempts shouldn't be retried")
+
+	assert.Nil(t, newRetrier(nil))
+	assert.Nil(t, newRetrier(&RetryPolicy{MaxAttempts: 1}))
+
+	_, err := NewGithubAPI(&GithubAPIArgs{ApplicationName: "test", Retry: &RetryPolicy{MaxAttempts: 3}})
+	assert.EqualError(t, err, "BaseDelay must be positive in RetryPolicy")
+}
+
+func TestSleepContext(t *testing.T) {
+	assert.NoError(t, sleepContext(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	assert.Equal(t, context.Canceled, sleepContext(ctx, time.Hour))
+	assert.True(t, time.Since(start) < time.Second, "Cancelling should end the wait")
+}
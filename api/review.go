@@ -0,0 +1,46 @@
+package api
+
+import (
+	"time"
+)
+
+// ReviewData represents a review submitted on a pull request
+type ReviewData struct {
+	ID       int      `json:"id"`
+	User     UserData `json:"user"`
+	Body     string   `json:"body"`
+	CommitID string   `json:"commit_id"`
+	// State is one of APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED or
+	// PENDING
+	State          string     `json:"state"`
+	HTMLURL        string     `json:"html_url"`
+	PullRequestURL string     `json:"pull_request_url"`
+	SubmittedAt    *time.Time `json:"submitted_at"`
+}
+
+// IsApproval reports whether the review approved the pull request
+func (r ReviewData) IsApproval() bool {
+	return r.State == "APPROVED"
+}
+
+// SubmittedBefore reports whether r was submitted before other. Pending
+// reviews have not been submitted, so sort after any submitted review
+func (r ReviewData) SubmittedBefore(other ReviewData) bool {
+	if r.SubmittedAt == nil || other.SubmittedAt == nil {
+		return r.SubmittedAt != nil
+	}
+
+	return r.SubmittedAt.Before(*other.SubmittedAt)
+}
+
+// CommentData represents a comment on an issue or pull request
+type CommentData struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	HTMLURL   string    `json:"html_url"`
+	IssueURL  string    `json:"issue_url"`
+	Body      string    `json:"body"`
+	User      UserData  `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SearchArgs specifies the qualifiers of a pull request search. Every
+// qualifier set must match. Logins may be given as "@me" for the
+// authenticated user
+type SearchArgs struct {
+	Author          string
+	Assignee        string
+	Reviewer        string
+	ReviewRequested string
+	// TeamReviewRequested is given as "org/team-slug"
+	TeamReviewRequested string
+	Involves            string
+	Org                 string
+	User                string
+	// Repo is given as "owner/name"
+	Repo string
+	// State is either open or closed
+	State string
+
+	// Query holds any further raw qualifiers, e.g. "draft:false label:bug"
+	Query string
+
+	// Sort is one of comments, created or updated. Defaults to best match
+	Sort string
+	// Order is one of asc or desc
+	Order string
+}
+
+func (a *SearchArgs) validate() error {
+	switch a.State {
+	case "", "open", "closed":
+	default:
+		return argUnsupported("State", a.State)
+	}
+
+	switch a.Sort {
+	case "", "comments", "created", "updated":
+	default:
+		return argUnsupported("Sort", a.Sort)
+	}
+
+	switch a.Order {
+	case "", "asc", "desc":
+	default:
+		return argUnsupported("Order", a.Order)
+	}
+
+	return nil
+}
+
+// query builds the search query of qualifiers, always restricted to PRs
+func (a *SearchArgs) query() string {
+	qualifiers := []string{"is:pr"}
+
+	add := func(name, value string) {
+		if len(value) != 0 {
+			qualifiers = append(qualifiers, fmt.Sprintf("%s:%s", name, value))
+		}
+	}
+	add("author", a.Author)
+	add("assignee", a.Assignee)
+	add("reviewed-by", a.Reviewer)
+	add("review-requested", a.ReviewRequested)
+	add("team-review-requested", a.TeamReviewRequested)
+	add("involves", a.Involves)
+	add("org", a.Org)
+	add("user", a.User)
+	add("repo", a.Repo)
+	add("state", a.State)
+
+	if query := strings.TrimSpace(a.Query); len(query) != 0 {
+		qualifiers = append(qualifiers, query)
+	}
+
+	return strings.Join(qualifiers, " ")
+}
+
+// Search is an interface for interacting with the search github api endpoint
+type Search interface {
+	PullRequests(args *SearchArgs) ([]IssueData, error)
+}
+
+type search struct {
+	g *ghAPI
+}
+
+// PullRequests finds every PR matching args across github. PRs are returned
+// as the issue side of each PR, see IssueData.PullRequestRef and
+// PullRequest.GetByRefs to fetch the full PullRequestData. github caps
+// searches at 1000 results
+func (s *search) PullRequests(args *SearchArgs) ([]IssueData, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{
+		"q": args.query(),
+	}
+	if len(args.Sort) != 0 {
+		values["sort"] = args.Sort
+	}
+	if len(args.Order) != 0 {
+		values["order"] = args.Order
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: "/search/issues",
+		method:   "GET",
+		values:   values,
+	}
+
+	results := make([]IssueData, 0)
+	if err := s.g.doFullPagination(reqArgs, extractSearchResults(&results)); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func extractSearchResults(results *[]IssueData) processFunc {
+	return func(resp *http.Response) error {
+		defer resp.Body.Close()
+
+		resultTmp := struct {
+			TotalCount        int         `json:"total_count"`
+			IncompleteResults bool        `json:"incomplete_results"`
+			Items             []IssueData `json:"items"`
+		}{}
+
+		decoder := json.NewDecoder(resp.Body)
+
+		if err := decoder.Decode(&resultTmp); err != nil {
+			return err
+		}
+		*results = append(*results, resultTmp.Items...)
+
+		return nil
+	}
+}
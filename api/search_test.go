@@ -0,0 +1,52 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchArgsQuery(t *testing.T) {
+	args := &SearchArgs{
+		TeamReviewRequested: "github/justice-league",
+		Org:                 "github",
+		State:               "open",
+		Query:               " draft:false ",
+	}
+
+	assert.Equal(t, "is:pr team-review-requested:github/justice-league org:github state:open draft:false", args.query())
+	assert.Equal(t, "is:pr author:@me", (&SearchArgs{Author: "@me"}).query())
+}
+
+func TestSearchPullRequests(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/search/issues", r.URL.Path)
+		assert.Equal(t, "is:pr review-requested:octocat state:open", r.URL.Query().Get("q"))
+		assert.Equal(t, "updated", r.URL.Query().Get("sort"))
+
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/search/issues?page=2>; rel="next", <http://%s/search/issues?page=2>; rel="last"`, r.Host, r.Host))
+			fmt.Fprint(w, `{"total_count": 2, "items": [{"number": 1347, "repository_url": "https://api.github.com/repos/octocat/Hello-World",
+				"pull_request": {"url": "https://api.github.com/repos/octocat/Hello-World/pulls/1347"}}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"total_count": 2, "items": [{"number": 1, "repository_url": "https://api.github.com/repos/octocat/Spoon-Knife"}]}`)
+	}))
+	defer server.Close()
+
+	results, err := g.Search().PullRequests(&SearchArgs{ReviewRequested: "octocat", State: "open", Sort: "updated"})
+	assert.NoError(t, err, "Should search PRs")
+	assert.Equal(t, 2, len(results), "Should follow pagination")
+
+	ref, ok := results[0].PullRequestRef()
+	assert.True(t, ok, "PR result should have a ref")
+	assert.Equal(t, PullRequestRef{Owner: "octocat", Repo: "Hello-World", Number: 1347}, ref)
+
+	_, ok = results[1].PullRequestRef()
+	assert.False(t, ok, "Plain issues should not have a PR ref")
+
+	_, err = g.Search().PullRequests(&SearchArgs{State: "merged"})
+	assert.Error(t, err, "Unknown state should be rejected")
+}
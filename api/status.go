@@ -0,0 +1,266 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// CIState is the aggregated state of every status and check run on a commit
+type CIState string
+
+const (
+	// CIStateNone means no statuses or check runs were reported
+	CIStateNone CIState = ""
+	// CIStateSuccess means every status and check run succeeded
+	CIStateSuccess CIState = "success"
+	// CIStateFailure means at least one status or check run failed
+	CIStateFailure CIState = "failure"
+	// CIStatePending means nothing has failed, but something is still running
+	CIStatePending CIState = "pending"
+)
+
+// Status is an interface for interacting with the commit status and check run
+// endpoints of the github api
+type Status interface {
+	Combined(args *StatusArgs) (*CombinedStatusData, error)
+	CheckRuns(args *StatusArgs) ([]CheckRunData, error)
+	CIState(args *StatusArgs) (CIState, error)
+}
+
+type status struct {
+	g *ghAPI
+}
+
+// StatusArgs specifies which commit to fetch statuses for
+type StatusArgs struct {
+	Owner string
+	Repo  string
+	// Ref may be a SHA, branch name or tag name
+	Ref string
+}
+
+// StatusArgsForPullRequest returns the StatusArgs for the head commit of pr.
+// The base repo is queried, since statuses for a fork's head are visible there
+func StatusArgsForPullRequest(pr PullRequestData) (*StatusArgs, error) {
+	if !pr.Base.HasRepo() {
+		return nil, ErrOwnerRepo
+	}
+
+	return &StatusArgs{
+		Owner: pr.Base.Repo.Owner.Login,
+		Repo:  pr.Base.Repo.Name,
+		Ref:   pr.Head.Sha,
+	}, nil
+}
+
+func (a *StatusArgs) validate() error {
+	if len(a.Owner) == 0 || len(a.Repo) == 0 {
+		return ErrOwnerRepo
+	}
+
+	if len(a.Ref) == 0 {
+		return ErrRef
+	}
+
+	return nil
+}
+
+// Combined fetches the combined commit status for the ref in args
+func (s *status) Combined(args *StatusArgs) (*CombinedStatusData, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: fmt.Sprintf("/repos/%s/%s/commits/%s/status", args.Owner, args.Repo, args.Ref),
+		method:   "GET",
+	}
+
+	var combined *CombinedStatusData
+	if err := s.g.doFullPagination(reqArgs, extractCombinedStatus(&combined)); err != nil {
+		return nil, err
+	}
+
+	return combined, nil
+}
+
+// CheckRuns fetches all check runs for the ref in args
+func (s *status) CheckRuns(args *StatusArgs) ([]CheckRunData, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: fmt.Sprintf("/repos/%s/%s/commits/%s/check-runs", args.Owner, args.Repo, args.Ref),
+		method:   "GET",
+	}
+
+	checkRuns := make([]CheckRunData, 0)
+	if err := s.g.doFullPagination(reqArgs, extractCheckRuns(&checkRuns)); err != nil {
+		return nil, err
+	}
+
+	return checkRuns, nil
+}
+
+// CIState fetches both the combined status and check runs for the ref in args
+// and aggregates them into a single CIState
+func (s *status) CIState(args *StatusArgs) (CIState, error) {
+	combined, err := s.Combined(args)
+	if err != nil {
+		return CIStateNone, err
+	}
+
+	checkRuns, err := s.CheckRuns(args)
+	if err != nil {
+		return CIStateNone, err
+	}
+
+	return AggregateCIState(combined, checkRuns), nil
+}
+
+// AggregateCIState reduces a combined status and check runs to a CIState.
+// Any failure wins over pending, which wins over success
+func AggregateCIState(combined *CombinedStatusData, checkRuns []CheckRunData) CIState {
+	states := make([]CIState, 0, len(checkRuns)+1)
+
+	// github reports "pending" for a commit with no statuses at all
+	if combined != nil && combined.TotalCount > 0 {
+		states = append(states, combinedState(combined.State))
+	}
+
+	for _, run := range checkRuns {
+		states = append(states, run.CIState())
+	}
+
+	result := CIStateNone
+	for _, state := range states {
+		switch state {
+		case CIStateFailure:
+			return CIStateFailure
+		case CIStatePending:
+			result = CIStatePending
+		case CIStateSuccess:
+			if result == CIStateNone {
+				result = CIStateSuccess
+			}
+		}
+	}
+
+	return result
+}
+
+func combinedState(state string) CIState {
+	switch state {
+	case "success":
+		return CIStateSuccess
+	case "pending":
+		return CIStatePending
+	default:
+		// "failure" and "error"
+		return CIStateFailure
+	}
+}
+
+func extractCombinedStatus(combined **CombinedStatusData) processFunc {
+	return func(resp *http.Response) error {
+		defer resp.Body.Close()
+
+		statusTmp := new(CombinedStatusData)
+
+		decoder := json.NewDecoder(resp.Body)
+
+		if err := decoder.Decode(statusTmp); err != nil {
+			return err
+		}
+
+		// only the statuses are paginated, the rest is repeated on each page
+		if *combined == nil {
+			*combined = statusTmp
+		} else {
+			(*combined).Statuses = append((*combined).Statuses, statusTmp.Statuses...)
+		}
+
+		return nil
+	}
+}
+
+func extractCheckRuns(checkRuns *[]CheckRunData) processFunc {
+	return func(resp *http.Response) error {
+		defer resp.Body.Close()
+
+		runsTmp := struct {
+			TotalCount int            `json:"total_count"`
+			CheckRuns  []CheckRunData `json:"check_runs"`
+		}{}
+
+		decoder := json.NewDecoder(resp.Body)
+
+		if err := decoder.Decode(&runsTmp); err != nil {
+			return err
+		}
+		*checkRuns = append(*checkRuns, runsTmp.CheckRuns...)
+
+		return nil
+	}
+}
+
+// CombinedStatusData represents the combined status of a commit
+type CombinedStatusData struct {
+	State      string       `json:"state"`
+	Sha        string       `json:"sha"`
+	TotalCount int          `json:"total_count"`
+	Statuses   []StatusData `json:"statuses"`
+	CommitURL  string       `json:"commit_url"`
+	URL        string       `json:"url"`
+}
+
+// StatusData represents a single commit status reported by an external service
+type StatusData struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url"`
+	AvatarURL   string    `json:"avatar_url"`
+	State       string    `json:"state"`
+	Description string    `json:"description"`
+	TargetURL   string    `json:"target_url"`
+	Context     string    `json:"context"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CheckRunData represents a check run reported by a github app
+type CheckRunData struct {
+	ID          int        `json:"id"`
+	HeadSha     string     `json:"head_sha"`
+	ExternalID  string     `json:"external_id"`
+	URL         string     `json:"url"`
+	HTMLURL     string     `json:"html_url"`
+	DetailsURL  string     `json:"details_url"`
+	Status      string     `json:"status"`
+	Conclusion  *string    `json:"conclusion"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Name        string     `json:"name"`
+	App         struct {
+		ID   int    `json:"id"`
+		Slug string `json:"slug"`
+		Name string `json:"name"`
+	} `json:"app"`
+}
+
+// CIState maps the status and conclusion of the check run to a CIState
+func (c CheckRunData) CIState() CIState {
+	if c.Status != "completed" || c.Conclusion == nil {
+		return CIStatePending
+	}
+
+	switch *c.Conclusion {
+	case "success", "neutral", "skipped":
+		return CIStateSuccess
+	default:
+		// failure, cancelled, timed_out, action_required, stale...
+		return CIStateFailure
+	}
+}
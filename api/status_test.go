@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRunCIState(t *testing.T) {
+	conclusion := func(c string) *string { return &c }
+
+	tests := []struct {
+		run   CheckRunData
+		state CIState
+	}{
+		{CheckRunData{Status: "queued"}, CIStatePending},
+		{CheckRunData{Status: "in_progress"}, CIStatePending},
+		{CheckRunData{Status: "completed", Conclusion: conclusion("success")}, CIStateSuccess},
+		{CheckRunData{Status: "completed", Conclusion: conclusion("skipped")}, CIStateSuccess},
+		{CheckRunData{Status: "completed", Conclusion: conclusion("timed_out")}, CIStateFailure},
+		{CheckRunData{Status: "completed", Conclusion: conclusion("failure")}, CIStateFailure},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.state, test.run.CIState())
+	}
+}
+
+func TestAggregateCIState(t *testing.T) {
+	success := "success"
+	passed := CheckRunData{Status: "completed", Conclusion: &success}
+	running := CheckRunData{Status: "in_progress"}
+
+	assert.Equal(t, CIStateNone, AggregateCIState(nil, nil), "Nothing reported should be none")
+	assert.Equal(t, CIStateNone, AggregateCIState(&CombinedStatusData{State: "pending"}, nil),
+		"An empty combined status should be ignored")
+	assert.Equal(t, CIStateSuccess, AggregateCIState(&CombinedStatusData{State: "success", TotalCount: 1}, []CheckRunData{passed}))
+	assert.Equal(t, CIStatePending, AggregateCIState(&CombinedStatusData{State: "success", TotalCount: 1}, []CheckRunData{running, passed}))
+	assert.Equal(t, CIStateFailure, AggregateCIState(&CombinedStatusData{State: "error", TotalCount: 2}, []CheckRunData{running}))
+}
+
+func TestStatusCIState(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/Hello-World/commits/6dcb09b/status":
+			fmt.Fprint(w, `{"state": "success", "total_count": 1, "statuses": [{"state": "success", "context": "ci/travis"}]}`)
+		case "/repos/octocat/Hello-World/commits/6dcb09b/check-runs":
+			fmt.Fprint(w, `{"total_count": 1, "check_runs": [{"name": "lint", "status": "completed", "conclusion": "failure"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	args := &StatusArgs{Owner: "octocat", Repo: "Hello-World", Ref: "6dcb09b"}
+
+	combined, err := g.Statuses().Combined(args)
+	assert.NoError(t, err, "Should fetch combined status")
+	assert.Equal(t, 1, len(combined.Statuses))
+
+	state, err := g.Statuses().CIState(args)
+	assert.NoError(t, err, "Should fetch CI state")
+	assert.Equal(t, CIStateFailure, state, "Failing check run should fail CI")
+
+	_, err = g.Statuses().CIState(&StatusArgs{Owner: "octocat", Repo: "Hello-World"})
+	assert.Equal(t, ErrRef, err, "Missing ref should be rejected")
+}
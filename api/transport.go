@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Middleware wraps the http.RoundTripper requests to github are made through,
+// e.g. to log, cache, authenticate or trace them
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function into an http.RoundTripper
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req)
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newClient returns a copy of client, or a new http.Client when nil, whose
+// transport is wrapped in middlewares. The first middleware is the outermost,
+// seeing each request first and each response last
+func newClient(client *http.Client, middlewares []Middleware) *http.Client {
+	c := &http.Client{}
+	if client != nil {
+		*c = *client
+	}
+
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+	c.Transport = transport
+
+	return c
+}
+
+// redactedHeaders are never logged
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// redactedParams are query parameters which are never logged
+var redactedParams = []string{"access_token", "client_secret"}
+
+const redacted = "REDACTED"
+
+// DebugLogging logs each request and response, their headers and how long the
+// response took at debug level. Credentials are redacted
+func DebugLogging(logger *logrus.Logger) Middleware {
+	log := logger.WithFields(logrus.Fields{"prefix": "GithubAPI"})
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if log.Logger.Level < logrus.DebugLevel {
+				return next.RoundTrip(req)
+			}
+
+			u := redactURL(req.URL)
+			log.Debugf("--> %s %s %s", req.Method, u, formatHeaders(req.Header))
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				log.Debugf("<-- %s %s failed after %s - %v", req.Method, u, time.Since(start), err)
+				return nil, err
+			}
+
+			log.Debugf("<-- %s %s %s in %s %s", req.Method, u, resp.Status, time.Since(start), formatHeaders(resp.Header))
+			return resp, nil
+		})
+	}
+}
+
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	changed := false
+	for _, param := range redactedParams {
+		if _, ok := query[param]; ok {
+			query.Set(param, redacted)
+			changed = true
+		}
+	}
+
+	if !changed && u.User == nil {
+		return u.String()
+	}
+
+	uNew := deepCopyURL(u)
+	uNew.User = nil
+	uNew.RawQuery = query.Encode()
+	return uNew.String()
+}
+
+// formatHeaders formats h as "{Name: value, ...}" sorted by name
+func formatHeaders(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(h[name], ", ")
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			value = redacted
+		}
+		fields = append(fields, name+": "+value)
+	}
+
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// Timeout fails each request, including reading its response body, which
+// takes longer than timeout. Unlike http.Client.Timeout, a retried request is
+// given the full timeout again
+func Timeout(timeout time.Duration) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if timeout <= 0 {
+				return next.RoundTrip(req)
+			}
+
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			resp, err := next.RoundTrip(req.WithContext(ctx))
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+
+			// the body is read after we return, so it holds the timeout
+			// until it's closed
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		})
+	}
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClientMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Join(r.Header["X-Order"], ","))
+	}))
+	defer server.Close()
+
+	order := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Add("X-Order", name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	base := &http.Client{Timeout: time.Minute}
+	client := newClient(base, []Middleware{order("first"), order("second")})
+	assert.Nil(t, base.Transport, "The given client shouldn't be modified")
+	assert.Equal(t, time.Minute, client.Timeout)
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "first,second", string(body), "The first middleware should see requests first")
+}
+
+func TestDebugLogging(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		fmt.Fprint(w, `{"login": "octocat"}`)
+	}))
+	defer server.Close()
+
+	logs := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.Out = logs
+	logger.Level = logrus.DebugLevel
+
+	g.token = "s3cr3t"
+	g.client = newClient(server.Client(), []Middleware{DebugLogging(logger)})
+
+	_, err := g.doRequest(&requestArgs{
+		endpoint: "/users/octocat",
+		method:   "GET",
+		values:   map[string]string{"access_token": "s3cr3t"},
+	})
+	assert.NoError(t, err)
+
+	assert.Contains(t, logs.String(), "--> GET "+server.URL+"/users/octocat?access_token=REDACTED")
+	assert.Contains(t, logs.String(), "Authorization: REDACTED")
+	assert.Contains(t, logs.String(), "<-- GET")
+	assert.Contains(t, logs.String(), "200 OK")
+	assert.NotContains(t, logs.String(), "s3cr3t")
+	assert.NotContains(t, logs.String(), "session=secret")
+
+	logs.Reset()
+	logger.Level = logrus.InfoLevel
+	_, err = g.doRequest(&requestArgs{endpoint: "/users/octocat", method: "GET"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", logs.String(), "Nothing should be logged above debug level")
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-body" {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+		}
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := newClient(server.Client(), []Middleware{Timeout(50 * time.Millisecond)})
+
+	_, err := client.Get(server.URL + "/slow")
+	assert.Error(t, err, "A slow response should time out")
+
+	resp, err := client.Get(server.URL + "/slow-body")
+	assert.NoError(t, err)
+	_, err = ioutil.ReadAll(resp.Body)
+	assert.Error(t, err, "A slow body should time out")
+	resp.Body.Close()
+}
@@ -1,5 +1,147 @@
 package api
 
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// User is an interface for interacting with the user, org member and team
+// endpoints of the github api
+type User interface {
+	Get(login string) (*UserProfileData, error)
+	Authenticated() (*UserProfileData, error)
+	OrgMembers(org string) ([]UserData, error)
+	OrgTeams(org string) ([]TeamData, error)
+	TeamMembers(org, teamSlug string) ([]UserData, error)
+}
+
+type user struct {
+	g *ghAPI
+}
+
+// Get fetches the public profile of the user with the given login
+func (u *user) Get(login string) (*UserProfileData, error) {
+	if len(login) == 0 {
+		return nil, ErrLogin
+	}
+
+	return u.getProfile(fmt.Sprintf("/users/%s", login))
+}
+
+// Authenticated fetches the profile of the user the token belongs to
+func (u *user) Authenticated() (*UserProfileData, error) {
+	if len(u.g.token) == 0 {
+		return nil, argMissingError("Token")
+	}
+
+	return u.getProfile("/user")
+}
+
+func (u *user) getProfile(endpoint string) (*UserProfileData, error) {
+	reqArgs := &requestArgs{
+		endpoint: endpoint,
+		method:   "GET",
+	}
+
+	profile := new(UserProfileData)
+	if err := u.g.doJSON(reqArgs, profile); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// OrgMembers fetches every member of org visible to the token
+func (u *user) OrgMembers(org string) ([]UserData, error) {
+	if len(org) == 0 {
+		return nil, ErrOrg
+	}
+
+	return u.getUsers(fmt.Sprintf("/orgs/%s/members", org))
+}
+
+// OrgTeams fetches every team in org visible to the token
+func (u *user) OrgTeams(org string) ([]TeamData, error) {
+	if len(org) == 0 {
+		return nil, ErrOrg
+	}
+
+	reqArgs := &requestArgs{
+		endpoint: fmt.Sprintf("/orgs/%s/teams", org),
+		method:   "GET",
+	}
+
+	teams := make([]TeamData, 0)
+	if err := u.g.doFullPagination(reqArgs, extractTeams(&teams)); err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// TeamMembers fetches every member of the team, including child teams
+func (u *user) TeamMembers(org, teamSlug string) ([]UserData, error) {
+	if len(org) == 0 {
+		return nil, ErrOrg
+	}
+
+	if len(teamSlug) == 0 {
+		return nil, ErrTeam
+	}
+
+	return u.getUsers(fmt.Sprintf("/orgs/%s/teams/%s/members", org, teamSlug))
+}
+
+func (u *user) getUsers(endpoint string) ([]UserData, error) {
+	reqArgs := &requestArgs{
+		endpoint: endpoint,
+		method:   "GET",
+	}
+
+	users := make([]UserData, 0)
+	if err := u.g.doFullPagination(reqArgs, extractUsers(&users)); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func extractUsers(users *[]UserData) processFunc {
+	return func(resp *http.Response) error {
+		defer resp.Body.Close()
+
+		userTmp := make([]UserData, 0)
+
+		decoder := json.NewDecoder(resp.Body)
+
+		if err := decoder.Decode(&userTmp); err != nil {
+			return err
+		}
+		*users = append(*users, userTmp...)
+
+		return nil
+	}
+}
+
+func extractTeams(teams *[]TeamData) processFunc {
+	return func(resp *http.Response) error {
+		defer resp.Body.Close()
+
+		teamTmp := make([]TeamData, 0)
+
+		decoder := json.NewDecoder(resp.Body)
+
+		if err := decoder.Decode(&teamTmp); err != nil {
+			return err
+		}
+		*teams = append(*teams, teamTmp...)
+
+		return nil
+	}
+}
+
 // UserData represents data about a user returned by the github api
 type UserData struct {
 	Login             string `json:"login"`
@@ -20,3 +162,45 @@ type UserData struct {
 	Type              string `json:"type"`
 	SiteAdmin         bool   `json:"site_admin"`
 }
+
+// UserProfileData represents the full profile of a user, only returned when
+// fetching a single user
+type UserProfileData struct {
+	UserData
+
+	Name        string    `json:"name"`
+	Company     string    `json:"company"`
+	Blog        string    `json:"blog"`
+	Location    string    `json:"location"`
+	Email       string    `json:"email"`
+	Bio         string    `json:"bio"`
+	PublicRepos int       `json:"public_repos"`
+	PublicGists int       `json:"public_gists"`
+	Followers   int       `json:"followers"`
+	Following   int       `json:"following"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DisplayName returns the name of the user if set, otherwise their login
+func (u UserProfileData) DisplayName() string {
+	if len(u.Name) != 0 {
+		return u.Name
+	}
+
+	return u.Login
+}
+
+// TeamData represents a team within an organization
+type TeamData struct {
+	ID              int    `json:"id"`
+	URL             string `json:"url"`
+	HTMLURL         string `json:"html_url"`
+	Name            string `json:"name"`
+	Slug            string `json:"slug"`
+	Description     string `json:"description"`
+	Privacy         string `json:"privacy"`
+	Permission      string `json:"permission"`
+	MembersURL      string `json:"members_url"`
+	RepositoriesURL string `json:"repositories_url"`
+}
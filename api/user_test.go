@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsers(t *testing.T) {
+	g, server := newTestAPI(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/octocat", "/user":
+			fmt.Fprint(w, `{"login": "octocat", "id": 1, "name": "monalisa octocat", "company": "GitHub"}`)
+		case "/orgs/github/teams":
+			fmt.Fprint(w, `[{"id": 1, "name": "Justice League", "slug": "justice-league"}]`)
+		case "/orgs/github/teams/justice-league/members", "/orgs/github/members":
+			fmt.Fprint(w, `[{"login": "octocat", "id": 1}, {"login": "hubot", "id": 2}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	profile, err := g.Users().Get("octocat")
+	assert.NoError(t, err, "Should fetch user")
+	assert.Equal(t, 1, profile.ID)
+	assert.Equal(t, "monalisa octocat", profile.DisplayName())
+
+	_, err = g.Users().Authenticated()
+	assert.Error(t, err, "Authenticated user requires a token")
+
+	g.token = "secret"
+	profile, err = g.Users().Authenticated()
+	assert.NoError(t, err, "Should fetch authenticated user")
+	assert.Equal(t, "octocat", profile.Login)
+
+	teams, err := g.Users().OrgTeams("github")
+	assert.NoError(t, err, "Should fetch org teams")
+	assert.Equal(t, "justice-league", teams[0].Slug)
+
+	members, err := g.Users().TeamMembers("github", "justice-league")
+	assert.NoError(t, err, "Should fetch team members")
+	assert.Equal(t, 2, len(members))
+
+	members, err = g.Users().OrgMembers("github")
+	assert.NoError(t, err, "Should fetch org members")
+	assert.Equal(t, 2, len(members))
+
+	_, err = g.Users().TeamMembers("github", "")
+	assert.Equal(t, ErrTeam, err)
+
+	_, err = g.Users().Get("ghost")
+	assert.True(t, IsNotFound(err), "Missing user should be a 404")
+
+	assert.Equal(t, "hubot", UserProfileData{UserData: UserData{Login: "hubot"}}.DisplayName(),
+		"Display name should fall back to the login")
+}
@@ -0,0 +1,199 @@
+// Package codeowners parses github CODEOWNERS files and evaluates which
+// owners are required to review changes to a set of paths
+package codeowners
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule assigns owners to the paths matching its pattern
+type Rule struct {
+	Pattern string
+	// Owners are "@user", "@org/team" or email addresses. A rule without
+	// owners leaves its paths unowned
+	Owners []string
+	// Line is where the rule is in the file
+	Line int
+
+	re *regexp.Regexp
+}
+
+// Match reports whether the rule applies to path
+func (r *Rule) Match(path string) bool {
+	return r.re.MatchString(strings.TrimPrefix(path, "/"))
+}
+
+// Ruleset is a parsed CODEOWNERS file
+type Ruleset struct {
+	Rules []Rule
+	// Errors are the lines which couldn't be parsed. As github does, they're
+	// skipped rather than failing the whole file
+	Errors []error
+}
+
+// Parse reads a CODEOWNERS file
+func Parse(r io.Reader) (*Ruleset, error) {
+	rs := &Ruleset{
+		Rules:  make([]Rule, 0),
+		Errors: make([]error, 0),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if idx := commentStart(line); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		pattern := strings.Replace(fields[0], `\#`, "#", -1)
+
+		re, err := compile(pattern)
+		if err != nil {
+			rs.Errors = append(rs.Errors, fmt.Errorf("line %d: %v", n, err))
+			continue
+		}
+
+		rs.Rules = append(rs.Rules, Rule{
+			Pattern: pattern,
+			Owners:  fields[1:],
+			Line:    n,
+			re:      re,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+// commentStart is the index of the # starting a comment in line, or -1.
+// Patterns may escape a # as \#
+func commentStart(line string) int {
+	for idx := 0; idx < len(line); idx++ {
+		if line[idx] != '#' {
+			continue
+		}
+		if idx > 0 && line[idx-1] == '\\' {
+			continue
+		}
+		return idx
+	}
+
+	return -1
+}
+
+// compile converts a gitignore style pattern into a regexp matching the paths
+// it owns. As in gitignore, a pattern without a slash other than a trailing
+// one matches at any depth, a pattern ending in a slash only matches a
+// directory's contents, and "**" matches any number of directories. Unlike
+// gitignore, "*" never matches into subdirectories, and negation and
+// character ranges aren't supported
+func compile(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "!") {
+		return nil, fmt.Errorf("negated pattern %q isn't supported", pattern)
+	}
+	if strings.ContainsAny(pattern, "[]") {
+		return nil, fmt.Errorf("character range in %q isn't supported", pattern)
+	}
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("empty pattern %q", pattern)
+	}
+
+	segments := strings.Split(trimmed, "/")
+
+	expr := new(bytes.Buffer)
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+
+	for idx, segment := range segments {
+		last := idx == len(segments)-1
+		if segment == "**" {
+			if last {
+				expr.WriteString(".*")
+			} else {
+				expr.WriteString("(?:[^/]+/)*")
+			}
+			continue
+		}
+
+		for _, c := range segment {
+			switch c {
+			case '*':
+				expr.WriteString("[^/]*")
+			case '?':
+				expr.WriteString("[^/]")
+			default:
+				expr.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		}
+		if !last {
+			expr.WriteString("/")
+		}
+	}
+
+	// a pattern naming a directory owns everything beneath it, but a
+	// wildcard in the last segment only matches the files it names
+	lastSegment := segments[len(segments)-1]
+	switch {
+	case dirOnly:
+		expr.WriteString("/.+")
+	case lastSegment != "**" && !strings.ContainsAny(lastSegment, "*?"):
+		expr.WriteString("(?:/.+)?")
+	}
+	expr.WriteString("$")
+
+	return regexp.Compile(expr.String())
+}
+
+// Owners returns the owners of path, given by the last rule matching it, and
+// whether any rule matched
+func (rs *Ruleset) Owners(path string) ([]string, bool) {
+	for idx := len(rs.Rules) - 1; idx >= 0; idx-- {
+		if rs.Rules[idx].Match(path) {
+			return rs.Rules[idx].Owners, true
+		}
+	}
+
+	return nil, false
+}
+
+// RequiredOwners returns every owner of any of paths, sorted and without
+// duplicates. Owners are compared case insensitively, as github logins are
+func (rs *Ruleset) RequiredOwners(paths []string) []string {
+	seen := make(map[string]bool)
+	owners := make([]string, 0)
+
+	for _, path := range paths {
+		pathOwners, _ := rs.Owners(path)
+		for _, owner := range pathOwners {
+			key := strings.ToLower(owner)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			owners = append(owners, owner)
+		}
+	}
+	sort.Strings(owners)
+
+	return owners
+}
@@ -0,0 +1,101 @@
+package codeowners
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// example is based on github's example CODEOWNERS file
+const example = `# Lines starting with '#' are comments.
+*       @global-owner1 @global-owner2
+
+# Order is important; the last matching pattern takes precedence.
+*.js    @js-owner
+*.go docs@example.com
+
+/build/logs/ @doctocat
+
+# The docs/* pattern doesn't match files in subdirectories of docs
+docs/*  docs@example.com
+
+apps/ @octocat
+/docs/ @doctocat
+/scripts/ @doctocat @octocat
+**/logs @octocat
+
+# Empty owners leave a path unowned
+/apps/github
+
+\#notes.txt @github/notes # a pattern may escape a #
+!negated @nobody
+[ab].txt @nobody
+`
+
+func TestParse(t *testing.T) {
+	rs, err := Parse(strings.NewReader(example))
+	assert.NoError(t, err)
+	assert.Equal(t, 11, len(rs.Rules))
+	assert.Equal(t, 2, len(rs.Errors), "Negation and character ranges should be skipped")
+
+	assert.Equal(t, Rule{Pattern: "#notes.txt", Owners: []string{"@github/notes"}, Line: 21}, Rule{
+		Pattern: rs.Rules[10].Pattern, Owners: rs.Rules[10].Owners, Line: rs.Rules[10].Line,
+	})
+	assert.Equal(t, []string{}, rs.Rules[9].Owners)
+}
+
+func TestOwners(t *testing.T) {
+	rs, err := Parse(strings.NewReader(example))
+	assert.NoError(t, err)
+
+	for path, want := range map[string][]string{
+		"README.md":                  {"@global-owner1", "@global-owner2"},
+		"web/app.js":                 {"@js-owner"},
+		"main.go":                    {"docs@example.com"},
+		"build/logs/today.txt":       {"@octocat"},
+		"docs/getting-started.md":    {"@doctocat"},
+		"docs/build-app/trouble.md":  {"@doctocat"},
+		"src/apps/main.c":            {"@octocat"},
+		"scripts/setup.sh":           {"@doctocat", "@octocat"},
+		"deeply/nested/logs/out.txt": {"@octocat"},
+		"apps/github/main.c":         {},
+		"#notes.txt":                 {"@github/notes"},
+	} {
+		owners, ok := rs.Owners(path)
+		assert.True(t, ok, path)
+		assert.Equal(t, want, owners, path)
+	}
+
+	empty, err := Parse(strings.NewReader("# no rules\n"))
+	assert.NoError(t, err)
+	_, ok := empty.Owners("README.md")
+	assert.False(t, ok)
+}
+
+func TestCompile(t *testing.T) {
+	for pattern, matches := range map[string]map[string]bool{
+		"/docs/*": {"docs/a.md": true, "docs/sub/b.md": false, "other/docs/a.md": false},
+		"docs/*":  {"docs/a.md": true, "docs/sub/b.md": false},
+		"docs":    {"docs": true, "docs/sub/b.md": true, "src/docs/a.md": true, "documents": false},
+		"apps/":   {"apps/a": true, "src/apps/a": true, "apps": false},
+		"a/**/b":  {"a/b": true, "a/x/y/b": true, "a/x/b/c.go": true, "ab": false},
+		"/a/**":   {"a/x": true, "a/x/y": true, "b/a/x": false},
+		"*.go":    {"main.go": true, "cmd/main.go": true, "main.go.txt": false},
+		"file?.c": {"file1.c": true, "file12.c": false},
+	} {
+		re, err := compile(pattern)
+		assert.NoError(t, err, pattern)
+		for path, want := range matches {
+			assert.Equal(t, want, re.MatchString(path), "%s matching %s", pattern, path)
+		}
+	}
+}
+
+func TestRequiredOwners(t *testing.T) {
+	rs, err := Parse(strings.NewReader(example))
+	assert.NoError(t, err)
+
+	owners := rs.RequiredOwners([]string{"web/app.js", "scripts/setup.sh", "docs/a.md", "apps/github/x", "lib/util.js"})
+	assert.Equal(t, []string{"@doctocat", "@js-owner", "@octocat"}, owners)
+}
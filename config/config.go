@@ -1,6 +1,10 @@
 package config
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -10,6 +14,28 @@ func init() {
 	viper.SetDefault("application_name", "gogitpr")
 	viper.SetDefault("log_level", "info")
 	viper.SetDefault("print", false)
+	viper.SetDefault("fetch_ci", false)
+	viper.SetDefault("milestone_report", false)
+	viper.SetDefault("fetch_users", false)
+	viper.SetDefault("pr_report", false)
+	viper.SetDefault("fetch_reviews", false)
+	viper.SetDefault("fetch_sizes", false)
+	viper.SetDefault("fetch_code_owners", false)
+	viper.SetDefault("owners_report", false)
+	viper.SetDefault("fetch_languages", false)
+	viper.SetDefault("skip_sync", false)
+	viper.SetDefault("skip_archived", false)
+	viper.SetDefault("skip_forks", false)
+	viper.SetDefault("listen_addr", ":8080")
+	viper.SetDefault("sync_interval", "15m")
+	viper.SetDefault("sync_jitter", "1m")
+	viper.SetDefault("status_file", "gogitpr-status.json")
+	viper.SetDefault("request_timeout", "30s")
+	viper.SetDefault("per_page", 100)
+	viper.SetDefault("follow_next_links", false)
+	viper.SetDefault("retry_max_attempts", 3)
+	viper.SetDefault("retry_base_delay", "1s")
+	viper.SetDefault("retry_max_delay", "30s")
 
 	viper.SetConfigName("gogitpr") // name of config file (without extension)
 	viper.SetConfigType("yaml")
@@ -35,14 +61,129 @@ type Config struct {
 	// github API
 	ApplicationName string
 
+	// APIVersion is the date version of the github REST API requested. When
+	// blank, api.DefaultAPIVersion is requested
+	APIVersion string
+
 	// GithubOrg is which github organization to populate DB from
 	GithubOrg string
 
 	// GithubUser is which github user to populate DB from
 	GithubUser string
 
+	// Search is a github search query of PR qualifiers, e.g.
+	// "review-requested:@me state:open". When set, only PRs found by the
+	// search are fetched, rather than every PR of every repo
+	Search string
+
+	// PRState is which PRs are fetched, one of "open", "closed" or "all".
+	// When blank, only open PRs are fetched, except by the stats command
+	// which fetches all
+	PRState string
+
+	// RepoType, RepoSort, SkipArchived, SkipForks, RepoTopics, RepoInclude
+	// and RepoExclude restrict which repos PRs are fetched from. See
+	// api.RepoArgs
+	RepoType     string
+	RepoSort     string
+	SkipArchived bool
+	SkipForks    bool
+	RepoTopics   []string
+	RepoInclude  []string
+	RepoExclude  []string
+
 	PrintResult bool
 
+	// ListenAddr is the address the serve command listens on
+	ListenAddr string
+
+	// WebhookSecret, when set, has the serve command accept github webhook
+	// deliveries signed with it at /webhook
+	WebhookSecret string
+
+	// SyncTargets are the users and orgs the daemon command syncs. When
+	// none are configured, GithubUser or GithubOrg is synced with Search
+	SyncTargets []SyncTarget
+
+	// SyncInterval is how often the daemon command syncs each target which
+	// doesn't set its own interval
+	SyncInterval time.Duration
+
+	// SyncJitter is the most each sync of the daemon command is randomly
+	// delayed by
+	SyncJitter time.Duration
+
+	// StatusFile is where the daemon command persists the status of the
+	// last sync of each target
+	StatusFile string
+
+	// RequestTimeout is the longest each github request may take, including
+	// reading its response. Zero disables the timeout
+	RequestTimeout time.Duration
+
+	// PerPage is the page size github lists are requested in, at most 100
+	PerPage int
+
+	// FollowNextLinks requests the pages of github lists by following their
+	// next links rather than by number
+	FollowNextLinks bool
+
+	// RetryMaxAttempts is the most each github request failing transiently
+	// is attempted. One disables retries
+	RetryMaxAttempts int
+
+	// RetryBaseDelay is waited before the first retry of a request, doubling
+	// before each retry after it up to RetryMaxDelay
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+
+	// SeedFile, if set, is an export imported into the DB before any command
+	// runs. Exports ending in .tar.gz or .tgz are read as archives
+	SeedFile string
+
+	// SkipSync has every command but daemon work from the DB as seeded,
+	// without fetching anything from github
+	SkipSync bool
+
+	// Stale configures the rules and notifiers of the stale command
+	Stale StaleConfig
+
+	// FetchCIState fetches the commit statuses and check runs for the head
+	// of every open PR
+	FetchCIState bool
+
+	// MilestoneReport fetches every milestone and prints PR counts grouped
+	// by milestone
+	MilestoneReport bool
+
+	// FetchUsers fetches the profile of every PR author, along with the
+	// teams of GithubOrg and their members
+	FetchUsers bool
+
+	// FetchReviews fetches the reviews of every PR
+	FetchReviews bool
+
+	// FetchSizes fetches every PR individually, as only then does github
+	// return the lines and files they change, and whether they conflict with
+	// their base
+	FetchSizes bool
+
+	// FetchCodeOwners evaluates the CODEOWNERS file of each open PR's repo
+	// against the files it changes, storing the owners required to review it
+	FetchCodeOwners bool
+
+	// OwnersReport prints the open PRs which lack an approval from any of
+	// their required owners
+	OwnersReport bool
+
+	// FetchLanguages fetches the languages of every repo synced, storing them
+	// with the repo
+	FetchLanguages bool
+
+	// PRReport prints a table of the PRs fetched with author display names
+	// and teams
+	PRReport bool
+
 	// Logger instance
 	Logger *logrus.Logger
 }
@@ -53,18 +194,135 @@ func NewConfig() (*Config, error) {
 	logger.Level = getLogLevel()
 
 	cfg := &Config{
-		BaseURL:         viper.GetString("base_url"),
-		GithubToken:     viper.GetString("github_token"),
-		ApplicationName: viper.GetString("application_name"),
-		GithubOrg:       viper.GetString("github_org"),
-		GithubUser:      viper.GetString("github_user"),
-		PrintResult:     viper.GetBool("print"),
-		Logger:          logger,
+		BaseURL:          viper.GetString("base_url"),
+		GithubToken:      viper.GetString("github_token"),
+		ApplicationName:  viper.GetString("application_name"),
+		GithubOrg:        viper.GetString("github_org"),
+		GithubUser:       viper.GetString("github_user"),
+		Search:           viper.GetString("search"),
+		PRState:          viper.GetString("pr_state"),
+		RepoType:         viper.GetString("repo_type"),
+		RepoSort:         viper.GetString("repo_sort"),
+		SkipArchived:     viper.GetBool("skip_archived"),
+		SkipForks:        viper.GetBool("skip_forks"),
+		RepoTopics:       getList("repo_topics"),
+		RepoInclude:      getList("repo_include"),
+		RepoExclude:      getList("repo_exclude"),
+		PrintResult:      viper.GetBool("print"),
+		ListenAddr:       viper.GetString("listen_addr"),
+		SyncInterval:     viper.GetDuration("sync_interval"),
+		SyncJitter:       viper.GetDuration("sync_jitter"),
+		StatusFile:       viper.GetString("status_file"),
+		APIVersion:       viper.GetString("api_version"),
+		RequestTimeout:   viper.GetDuration("request_timeout"),
+		PerPage:          viper.GetInt("per_page"),
+		FollowNextLinks:  viper.GetBool("follow_next_links"),
+		RetryMaxAttempts: viper.GetInt("retry_max_attempts"),
+		RetryBaseDelay:   viper.GetDuration("retry_base_delay"),
+		RetryMaxDelay:    viper.GetDuration("retry_max_delay"),
+		SeedFile:         viper.GetString("seed_file"),
+		SkipSync:         viper.GetBool("skip_sync"),
+		WebhookSecret:    viper.GetString("webhook_secret"),
+		FetchCIState:     viper.GetBool("fetch_ci"),
+		MilestoneReport:  viper.GetBool("milestone_report"),
+		FetchUsers:       viper.GetBool("fetch_users"),
+		FetchReviews:     viper.GetBool("fetch_reviews"),
+		FetchSizes:       viper.GetBool("fetch_sizes"),
+		FetchCodeOwners:  viper.GetBool("fetch_code_owners"),
+		OwnersReport:     viper.GetBool("owners_report"),
+		FetchLanguages:   viper.GetBool("fetch_languages"),
+		PRReport:         viper.GetBool("pr_report"),
+		Logger:           logger,
+	}
+
+	if err := viper.UnmarshalKey("sync_targets", &cfg.SyncTargets); err != nil {
+		return nil, fmt.Errorf("parsing sync_targets: %v", err)
+	}
+
+	if err := viper.UnmarshalKey("stale", &cfg.Stale); err != nil {
+		return nil, fmt.Errorf("parsing stale: %v", err)
 	}
 
 	return cfg, nil
 }
 
+// SyncTarget is a user or org synced by the daemon command, e.g.
+//
+//	sync_targets:
+//	  - org: coreos
+//	    interval: 5m
+//	  - user: doodles526
+//	    search: "state:open"
+type SyncTarget struct {
+	Name     string        `mapstructure:"name"`
+	User     string        `mapstructure:"user"`
+	Org      string        `mapstructure:"org"`
+	Search   string        `mapstructure:"search"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// StaleConfig is set in the YAML file, e.g.
+//
+//	stale:
+//	  default:
+//	    inactive_days: 14
+//	    missing_reviewers: true
+//	  repos:
+//	    coreos/etcd:
+//	      inactive_days: 7
+//	      merge_conflicts: true
+//	  notify:
+//	    - type: stdout
+//	    - type: webhook
+//	      url: https://chat.example.com/hooks/stale
+type StaleConfig struct {
+	Default StaleRules `mapstructure:"default"`
+	// Repos are keyed by owner/name, and replace Default entirely
+	Repos     map[string]StaleRules `mapstructure:"repos"`
+	Notifiers []StaleNotifier       `mapstructure:"notify"`
+}
+
+// StaleRules decide which PRs are stale. See stale.Rules
+type StaleRules struct {
+	InactiveDays     int  `mapstructure:"inactive_days"`
+	MissingReviewers bool `mapstructure:"missing_reviewers"`
+	DraftDays        int  `mapstructure:"draft_days"`
+	MergeConflicts   bool `mapstructure:"merge_conflicts"`
+}
+
+// StaleNotifier is where stale PRs are reported. Type is one of "stdout",
+// "webhook", which uses URL, or "smtp", which uses the remaining fields
+type StaleNotifier struct {
+	Type     string   `mapstructure:"type"`
+	URL      string   `mapstructure:"url"`
+	Addr     string   `mapstructure:"addr"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	Subject  string   `mapstructure:"subject"`
+}
+
+// getList reads key as either a YAML list or a comma separated string, as
+// given by an envvar
+func getList(key string) []string {
+	list := make([]string, 0)
+	if items, ok := viper.Get(key).([]interface{}); ok {
+		for _, item := range items {
+			list = append(list, fmt.Sprint(item))
+		}
+		return list
+	}
+
+	for _, item := range strings.Split(viper.GetString(key), ",") {
+		if item = strings.TrimSpace(item); len(item) != 0 {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
 func getLogLevel() logrus.Level {
 	level, err := logrus.ParseLevel(viper.GetString("log_level"))
 	if err != nil {
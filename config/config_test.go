@@ -0,0 +1,21 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConfigAPIVersion(t *testing.T) {
+	cfg, err := NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "", cfg.APIVersion, "A blank version leaves the api package to request its default")
+
+	os.Setenv("GITPR_API_VERSION", "2026-03-10")
+	defer os.Unsetenv("GITPR_API_VERSION")
+
+	cfg, err = NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-03-10", cfg.APIVersion, "GITPR_API_VERSION should set the API version")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+
+	"github.com/doodles526/gogitpr/config"
+	"github.com/doodles526/gogitpr/scheduler"
+	"github.com/doodles526/gogitpr/telemetry"
+	"github.com/pkg/errors"
+)
+
+// runDaemon serves pull requests like runServe, while keeping them up to date
+// by syncing every target on its interval until interrupted
+func runDaemon(s *syncer, reg *telemetry.Registry) error {
+	cfg := s.cfg
+
+	sched, err := scheduler.NewScheduler(&scheduler.Args{
+		Targets:  daemonTargets(cfg),
+		Interval: cfg.SyncInterval,
+		Jitter:   cfg.SyncJitter,
+		Sync: func(ctx context.Context, target scheduler.Target) error {
+			return s.syncTarget(ctx, target)
+		},
+		StatusFile: cfg.StatusFile,
+		Logger:     cfg.Logger,
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating scheduler")
+	}
+
+	srv, err := newServer(s, reg)
+	if err != nil {
+		return err
+	}
+	srv.Handle("/healthz", sched.HealthHandler())
+	srv.Handle("/readyz", sched.ReadyHandler())
+
+	ctx, cancel := context.WithCancel(signalContext())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- sched.Run(ctx)
+	}()
+
+	serveErr := srv.ListenAndServe(ctx)
+	// stop scheduling if the server failed, and wait for syncs in flight
+	cancel()
+	if err := <-done; err != nil {
+		return errors.Wrap(err, "running scheduler")
+	}
+
+	return serveErr
+}
+
+// daemonTargets returns the configured sync targets, falling back to the
+// configured user or org
+func daemonTargets(cfg *config.Config) []scheduler.Target {
+	if len(cfg.SyncTargets) == 0 {
+		return []scheduler.Target{defaultTarget(cfg)}
+	}
+
+	targets := make([]scheduler.Target, 0, len(cfg.SyncTargets))
+	for _, t := range cfg.SyncTargets {
+		targets = append(targets, scheduler.Target{
+			Name:     t.Name,
+			User:     t.User,
+			Org:      t.Org,
+			Search:   t.Search,
+			Interval: t.Interval,
+		})
+	}
+
+	return targets
+}
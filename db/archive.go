@@ -0,0 +1,168 @@
+package db
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ManifestName is the first file of an archive, holding its Header
+const ManifestName = "manifest.json"
+
+// archiveFiles maps each kind of record to the file of an archive holding
+// them, in the order they are written
+var archiveFiles = []struct {
+	kind string
+	name string
+}{
+	{KindRepo, "repos.ndjson"},
+	{KindUser, "users.ndjson"},
+	{KindTeam, "teams.ndjson"},
+	{KindMilestone, "milestones.ndjson"},
+	{KindPullRequest, "pull_requests.ndjson"},
+}
+
+// ExportArchive writes everything stored in d to w as a gzipped tar, holding
+// a manifest followed by an NDJSON file per kind of record
+func ExportArchive(d DB, w io.Writer) error {
+	s, err := readSnapshot(d)
+	if err != nil {
+		return errors.Wrap(err, "reading DB")
+	}
+
+	header := s.header()
+	files := make(map[string]*bytes.Buffer)
+	for _, f := range archiveFiles {
+		files[f.kind] = new(bytes.Buffer)
+	}
+
+	err = s.records(func(kind string, data interface{}) error {
+		return json.NewEncoder(files[kind]).Encode(data)
+	})
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest, err := json.MarshalIndent(header, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeArchiveFile(tw, ManifestName, manifest, header.ExportedAt); err != nil {
+		return err
+	}
+
+	for _, f := range archiveFiles {
+		if err := writeArchiveFile(tw, f.name, files[f.kind].Bytes(), header.ExportedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+func writeArchiveFile(tw *tar.Writer, name string, contents []byte, modTime time.Time) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(contents)),
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(contents)
+
+	return err
+}
+
+// ImportArchive stores every record of the archive read from r in d,
+// replacing whatever d already holds with the same IDs. It returns the header
+// of the archive
+func ImportArchive(d DB, r io.Reader) (*Header, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	kinds := make(map[string]string)
+	for _, f := range archiveFiles {
+		kinds[f.name] = f.kind
+	}
+
+	tr := tar.NewReader(gz)
+	var header *Header
+	for {
+		th, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if header == nil {
+			if th.Name != ManifestName {
+				return nil, fmt.Errorf("expected %s first in archive, got %s", ManifestName, th.Name)
+			}
+
+			header = new(Header)
+			if err := json.NewDecoder(tr).Decode(header); err != nil {
+				return nil, errors.Wrap(err, ManifestName)
+			}
+			if err := header.validate(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		kind, ok := kinds[th.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown file %s in archive", th.Name)
+		}
+
+		if err := importArchiveFile(d, kind, tr); err != nil {
+			return nil, errors.Wrap(err, th.Name)
+		}
+	}
+
+	if header == nil {
+		return nil, fmt.Errorf("archive is empty")
+	}
+
+	return header, nil
+}
+
+func importArchiveFile(d DB, kind string, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		if err := importRecord(d, kind, scanner.Bytes()); err != nil {
+			return errors.Wrapf(err, "line %d", line)
+		}
+	}
+
+	return scanner.Err()
+}
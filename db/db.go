@@ -1,14 +1,24 @@
 package db
 
 import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/doodles526/gogitpr/api"
 	"github.com/sirupsen/logrus"
 )
 
 // PRFilterFunc should return true if we should return this particular PR
-// Only return an error if unrecoverable
+// Only return an error if unrecoverable. A nil PRFilterFunc matches every PR
 type PRFilterFunc func(pr api.PullRequestData) (bool, error)
 
+// RepoFilterFunc should return true if we should return this particular repo.
+// Only return an error if unrecoverable. A nil RepoFilterFunc matches every
+// repo
+type RepoFilterFunc func(r Repo) (bool, error)
+
 // DB is an abstraction on top of whatever backing store exists
 // for purposes of interview brevity this will just be an in-mem
 // storage. But easily extendable to a persisted DB like RethinkDB
@@ -18,6 +28,79 @@ type DB interface {
 	GetAllPullRequests() ([]api.PullRequestData, error)
 	GetFilterPullRequests(f PRFilterFunc) ([]api.PullRequestData, error)
 	GetPullRequestByID(id int) (api.PullRequestData, bool, error)
+	GetPullRequestByNumber(repoFullName string, number int) (api.PullRequestData, bool, error)
+
+	StoreCIState(prID int, state api.CIState) error
+	GetCIState(prID int) (api.CIState, bool, error)
+
+	StoreClosingIssues(prID int, refs []api.IssueReference) error
+	GetClosingIssues(prID int) ([]api.IssueReference, bool, error)
+
+	StoreMilestone(m api.MilestoneData) error
+	StoreMilestoneBatch(ms []api.MilestoneData) error
+	GetAllMilestones() ([]api.MilestoneData, error)
+	GetMilestoneByID(id int) (api.MilestoneData, bool, error)
+
+	StoreUser(u api.UserProfileData) error
+	GetUserByID(id int) (api.UserProfileData, bool, error)
+	GetUserByLogin(login string) (api.UserProfileData, bool, error)
+	GetAllUsers() ([]api.UserProfileData, error)
+
+	StoreTeam(team api.TeamData, members []api.UserData) error
+	GetAllTeams() ([]api.TeamData, error)
+	GetTeamsByUserID(id int) ([]api.TeamData, error)
+	GetTeamMemberIDs(id int) ([]int, error)
+
+	StoreReview(prID int, review api.ReviewData) error
+	GetReviews(prID int) ([]api.ReviewData, error)
+
+	StoreRequiredOwners(prID int, owners []string) error
+	GetRequiredOwners(prID int) ([]string, bool, error)
+
+	StoreRepo(r Repo) error
+	GetRepo(fullName string) (Repo, bool, error)
+	ListRepos(f RepoFilterFunc) ([]Repo, error)
+}
+
+// Repo is a repo as last synced, along with what was fetched about it. Repos
+// are only stored once synced, not because a PR references them
+type Repo struct {
+	api.RepoData
+	// Languages maps each language of the repo to the bytes of code written
+	// in it. It is nil unless languages were fetched
+	Languages map[string]int `json:"languages,omitempty"`
+	// PreviousNames are the full names the repo was stored under before it
+	// was renamed or transferred, oldest first
+	PreviousNames []string `json:"previous_names,omitempty"`
+	// SyncedAt is when a sync last found the repo
+	SyncedAt time.Time `json:"synced_at"`
+	// Missing is set once a sync of the repo's owner no longer finds it, as
+	// it was deleted, transferred or no longer matches the repo filter
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Renamed reports whether the repo has been stored under another name
+func (r Repo) Renamed() bool {
+	return len(r.PreviousNames) != 0
+}
+
+// copy returns r without any map or slice shared with the original
+func (r Repo) copy() Repo {
+	if r.Topics != nil {
+		r.Topics = append([]string{}, r.Topics...)
+	}
+	if r.PreviousNames != nil {
+		r.PreviousNames = append([]string{}, r.PreviousNames...)
+	}
+	if r.Languages != nil {
+		languages := make(map[string]int, len(r.Languages))
+		for lang, bytes := range r.Languages {
+			languages[lang] = bytes
+		}
+		r.Languages = languages
+	}
+
+	return r
 }
 
 // Args is currently empty, as to be forward compatible
@@ -29,50 +112,130 @@ type Args struct {
 // NewDB returns a new DB object
 func NewDB(args *Args) (DB, error) {
 	return &inMem{
-		pullRequests: make([]api.PullRequestData, 0),
-		idIndex:      make(map[int]*api.PullRequestData),
-		logger:       args.Logger.WithFields(logrus.Fields{"prefix": "DB"}),
+		pullRequests:   make([]api.PullRequestData, 0),
+		idIndex:        make(map[int]*api.PullRequestData),
+		prUsers:        make(map[int]api.UserData),
+		repos:          make(map[int]api.RepoData),
+		ciStates:       make(map[int]api.CIState),
+		closingIssues:  make(map[int][]api.IssueReference),
+		milestones:     make(map[int]api.MilestoneData),
+		users:          make(map[int]api.UserProfileData),
+		loginIndex:     make(map[string]int),
+		teams:          make(map[int]api.TeamData),
+		teamMembers:    make(map[int][]int),
+		reviews:        make(map[int][]api.ReviewData),
+		requiredOwners: make(map[int][]string),
+		syncedRepos:    make(map[int]Repo),
+		repoNames:      make(map[string]int),
+		oldRepoNames:   make(map[string]int),
+		logger:         args.Logger.WithFields(logrus.Fields{"prefix": "DB"}),
 	}, nil
 }
 
-// inMem is not concurrency safe. The client must perform locking if they wish
-// to have safe concurrent read/write access
+// inMem is safe for concurrent use. Filter funcs are run without holding the
+// lock, so they may call back into the DB
 type inMem struct {
+	mu sync.RWMutex
+
+	// pullRequests are normalized, see normalize.go
 	pullRequests []api.PullRequestData
 
 	idIndex map[int]*api.PullRequestData
-	logger  *logrus.Entry
+	// prUsers and repos are those referenced by pullRequests, keyed by ID
+	prUsers map[int]api.UserData
+	repos   map[int]api.RepoData
+	// ciStates is keyed by PR ID
+	ciStates map[int]api.CIState
+	// closingIssues is keyed by PR ID
+	closingIssues map[int][]api.IssueReference
+	// milestones is keyed by milestone ID
+	milestones map[int]api.MilestoneData
+	// users is keyed by user ID, loginIndex maps logins to those IDs
+	users      map[int]api.UserProfileData
+	loginIndex map[string]int
+	// teams and teamMembers are keyed by team ID
+	teams       map[int]api.TeamData
+	teamMembers map[int][]int
+	// reviews is keyed by PR ID
+	reviews map[int][]api.ReviewData
+	// requiredOwners is keyed by PR ID
+	requiredOwners map[int][]string
+	// syncedRepos is keyed by repo ID. repoNames maps the lower cased full
+	// names of those repos to their IDs, and oldRepoNames their previous names
+	syncedRepos  map[int]Repo
+	repoNames    map[string]int
+	oldRepoNames map[string]int
+	logger       *logrus.Entry
 }
 
+// StorePullRequest replaces any PR already stored with the same ID
 func (i *inMem) StorePullRequest(pr api.PullRequestData) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.storePullRequest(pr)
+
+	return nil
+}
+
+func (i *inMem) storePullRequest(pr api.PullRequestData) {
+	pr = i.normalize(pr)
+
+	if stored, ok := i.idIndex[pr.ID]; ok {
+		*stored = pr
+		return
+	}
+
+	oldCap := cap(i.pullRequests)
 	i.pullRequests = append(i.pullRequests, pr)
 
+	if cap(i.pullRequests) != oldCap {
+		// append moved the backing array, so every pointer must be updated
+		i.reindex()
+		return
+	}
 	i.idIndex[pr.ID] = &i.pullRequests[len(i.pullRequests)-1]
+}
 
-	return nil
+func (i *inMem) reindex() {
+	for idx := range i.pullRequests {
+		i.idIndex[i.pullRequests[idx].ID] = &i.pullRequests[idx]
+	}
 }
 
 func (i *inMem) StorePullRequestBatch(prs []api.PullRequestData) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	for _, pr := range prs {
-		if err := i.StorePullRequest(pr); err != nil {
-			return err
-		}
+		i.storePullRequest(pr)
 	}
 
 	return nil
 }
 
 func (i *inMem) GetAllPullRequests() ([]api.PullRequestData, error) {
-	prTemp := make([]api.PullRequestData, len(i.pullRequests))
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
 	// copy so we don't pass the backing store's copy of the slice
-	copy(prTemp, i.pullRequests)
+	prTemp := make([]api.PullRequestData, 0, len(i.pullRequests))
+	for _, pr := range i.pullRequests {
+		prTemp = append(prTemp, i.denormalize(pr))
+	}
 
 	return prTemp, nil
 }
 
 func (i *inMem) GetFilterPullRequests(f PRFilterFunc) ([]api.PullRequestData, error) {
+	// filter a copy so f is free to call back into the DB
+	prs, err := i.GetAllPullRequests()
+	if err != nil || f == nil {
+		return prs, err
+	}
+
 	prTemp := make([]api.PullRequestData, 0)
-	for _, pr := range i.pullRequests {
+	for _, pr := range prs {
 		ok, err := f(pr)
 		if err != nil {
 			return nil, err
@@ -86,12 +249,407 @@ func (i *inMem) GetFilterPullRequests(f PRFilterFunc) ([]api.PullRequestData, er
 }
 
 func (i *inMem) GetPullRequestByID(id int) (api.PullRequestData, bool, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
 	pr, ok := i.idIndex[id]
 	if !ok {
 		return api.PullRequestData{}, false, nil
 	}
-	newPR := new(api.PullRequestData)
-	*newPR = *pr
 
-	return *newPR, true, nil
+	return i.denormalize(*pr), true, nil
+}
+
+// GetPullRequestByNumber finds a PR by the owner/name of its base repo and
+// its number
+func (i *inMem) GetPullRequestByNumber(repoFullName string, number int) (api.PullRequestData, bool, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for _, pr := range i.pullRequests {
+		if pr.Number != number {
+			continue
+		}
+
+		pr = i.denormalize(pr)
+		if pr.Base.HasRepo() && pr.Base.Repo.FullName == repoFullName {
+			return pr, true, nil
+		}
+	}
+
+	return api.PullRequestData{}, false, nil
+}
+
+func (i *inMem) StoreCIState(prID int, state api.CIState) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.ciStates[prID] = state
+
+	return nil
+}
+
+func (i *inMem) GetCIState(prID int) (api.CIState, bool, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	state, ok := i.ciStates[prID]
+
+	return state, ok, nil
+}
+
+func (i *inMem) StoreClosingIssues(prID int, refs []api.IssueReference) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	refsTemp := make([]api.IssueReference, len(refs))
+	copy(refsTemp, refs)
+	i.closingIssues[prID] = refsTemp
+
+	return nil
+}
+
+func (i *inMem) GetClosingIssues(prID int) ([]api.IssueReference, bool, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	refs, ok := i.closingIssues[prID]
+	if !ok {
+		return nil, false, nil
+	}
+	refsTemp := make([]api.IssueReference, len(refs))
+	copy(refsTemp, refs)
+
+	return refsTemp, true, nil
+}
+
+func (i *inMem) StoreMilestone(m api.MilestoneData) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.milestones[m.ID] = m
+
+	return nil
+}
+
+func (i *inMem) StoreMilestoneBatch(ms []api.MilestoneData) error {
+	for _, m := range ms {
+		if err := i.StoreMilestone(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i *inMem) GetAllMilestones() ([]api.MilestoneData, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	msTemp := make([]api.MilestoneData, 0, len(i.milestones))
+	for _, m := range i.milestones {
+		msTemp = append(msTemp, m)
+	}
+
+	return msTemp, nil
+}
+
+func (i *inMem) GetMilestoneByID(id int) (api.MilestoneData, bool, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	m, ok := i.milestones[id]
+
+	return m, ok, nil
+}
+
+func (i *inMem) StoreUser(u api.UserProfileData) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if old, ok := i.users[u.ID]; ok && old.Login != u.Login {
+		// the user has been renamed since we last saw them, and someone else
+		// may have taken the old login already
+		if i.loginIndex[old.Login] == u.ID {
+			delete(i.loginIndex, old.Login)
+		}
+	}
+	i.users[u.ID] = u
+	i.loginIndex[u.Login] = u.ID
+
+	return nil
+}
+
+func (i *inMem) GetUserByID(id int) (api.UserProfileData, bool, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	u, ok := i.users[id]
+
+	return u, ok, nil
+}
+
+func (i *inMem) GetUserByLogin(login string) (api.UserProfileData, bool, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	id, ok := i.loginIndex[login]
+	if !ok {
+		return api.UserProfileData{}, false, nil
+	}
+	u, ok := i.users[id]
+
+	return u, ok, nil
+}
+
+// GetAllUsers returns every user ordered by ID
+func (i *inMem) GetAllUsers() ([]api.UserProfileData, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	usersTemp := make([]api.UserProfileData, 0, len(i.users))
+	for _, u := range i.users {
+		usersTemp = append(usersTemp, u)
+	}
+	sort.Slice(usersTemp, func(a, b int) bool {
+		return usersTemp[a].ID < usersTemp[b].ID
+	})
+
+	return usersTemp, nil
+}
+
+func (i *inMem) StoreTeam(team api.TeamData, members []api.UserData) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ids := make([]int, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.ID)
+	}
+	i.teams[team.ID] = team
+	i.teamMembers[team.ID] = ids
+
+	return nil
+}
+
+func (i *inMem) GetAllTeams() ([]api.TeamData, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	teamsTemp := make([]api.TeamData, 0, len(i.teams))
+	for _, team := range i.teams {
+		teamsTemp = append(teamsTemp, team)
+	}
+	sortTeams(teamsTemp)
+
+	return teamsTemp, nil
+}
+
+func (i *inMem) GetTeamsByUserID(id int) ([]api.TeamData, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	teamsTemp := make([]api.TeamData, 0)
+	for teamID, members := range i.teamMembers {
+		for _, member := range members {
+			if member == id {
+				teamsTemp = append(teamsTemp, i.teams[teamID])
+				break
+			}
+		}
+	}
+	sortTeams(teamsTemp)
+
+	return teamsTemp, nil
+}
+
+func (i *inMem) GetTeamMemberIDs(id int) ([]int, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	idsTemp := make([]int, len(i.teamMembers[id]))
+	copy(idsTemp, i.teamMembers[id])
+
+	return idsTemp, nil
+}
+
+func sortTeams(teams []api.TeamData) {
+	sort.Slice(teams, func(a, b int) bool {
+		return teams[a].Slug < teams[b].Slug
+	})
+}
+
+// StoreReview replaces any review already stored with the same ID, keeping the
+// reviews of each PR ordered by submission
+func (i *inMem) StoreReview(prID int, review api.ReviewData) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	reviews := i.reviews[prID]
+	replaced := false
+	for idx := range reviews {
+		if reviews[idx].ID == review.ID {
+			reviews[idx] = review
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		reviews = append(reviews, review)
+	}
+
+	sort.SliceStable(reviews, func(a, b int) bool {
+		return reviews[a].SubmittedBefore(reviews[b])
+	})
+	i.reviews[prID] = reviews
+
+	return nil
+}
+
+func (i *inMem) GetReviews(prID int) ([]api.ReviewData, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	reviewsTemp := make([]api.ReviewData, len(i.reviews[prID]))
+	copy(reviewsTemp, i.reviews[prID])
+
+	return reviewsTemp, nil
+}
+
+// StoreRequiredOwners replaces the code owners required to review a PR
+func (i *inMem) StoreRequiredOwners(prID int, owners []string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ownersTemp := make([]string, len(owners))
+	copy(ownersTemp, owners)
+	i.requiredOwners[prID] = ownersTemp
+
+	return nil
+}
+
+func (i *inMem) GetRequiredOwners(prID int) ([]string, bool, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	owners, ok := i.requiredOwners[prID]
+	if !ok {
+		return nil, false, nil
+	}
+	ownersTemp := make([]string, len(owners))
+	copy(ownersTemp, owners)
+
+	return ownersTemp, true, nil
+}
+
+// StoreRepo replaces any repo already stored with the same ID. If the repo
+// has been renamed since, the name it was stored under is added to its
+// PreviousNames. Languages are kept from the stored repo unless given
+func (i *inMem) StoreRepo(r Repo) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	r = r.copy()
+	if old, ok := i.syncedRepos[r.ID]; ok {
+		for _, name := range old.PreviousNames {
+			r.PreviousNames = appendName(r.PreviousNames, name)
+		}
+		if !strings.EqualFold(old.FullName, r.FullName) {
+			// another repo may have taken the old name already
+			if id := i.repoNames[strings.ToLower(old.FullName)]; id == r.ID {
+				delete(i.repoNames, strings.ToLower(old.FullName))
+			}
+			r.PreviousNames = appendName(r.PreviousNames, old.FullName)
+		}
+		if r.Languages == nil {
+			r.Languages = old.copy().Languages
+		}
+	}
+	r.PreviousNames = removeName(r.PreviousNames, r.FullName)
+
+	i.syncedRepos[r.ID] = r
+	i.repoNames[strings.ToLower(r.FullName)] = r.ID
+	for _, name := range r.PreviousNames {
+		i.oldRepoNames[strings.ToLower(name)] = r.ID
+	}
+
+	return nil
+}
+
+// appendName appends name to names unless it's already there
+func appendName(names []string, name string) []string {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return names
+		}
+	}
+
+	return append(names, name)
+}
+
+// removeName removes name from names, as when a repo is renamed back
+func removeName(names []string, name string) []string {
+	kept := names[:0]
+	for _, n := range names {
+		if !strings.EqualFold(n, name) {
+			kept = append(kept, n)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	return kept
+}
+
+// GetRepo finds a repo by its owner/name, ignoring case as github does. A repo
+// which has since been renamed is found by its previous names too, unless
+// another repo has taken the name
+func (i *inMem) GetRepo(fullName string) (Repo, bool, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	name := strings.ToLower(fullName)
+	id, ok := i.repoNames[name]
+	if !ok {
+		id, ok = i.oldRepoNames[name]
+	}
+	if !ok {
+		return Repo{}, false, nil
+	}
+	r, ok := i.syncedRepos[id]
+
+	return r.copy(), ok, nil
+}
+
+// ListRepos returns the repos f matches ordered by full name
+func (i *inMem) ListRepos(f RepoFilterFunc) ([]Repo, error) {
+	i.mu.RLock()
+	reposTemp := make([]Repo, 0, len(i.syncedRepos))
+	for _, r := range i.syncedRepos {
+		reposTemp = append(reposTemp, r.copy())
+	}
+	i.mu.RUnlock()
+
+	sort.Slice(reposTemp, func(a, b int) bool {
+		return reposTemp[a].FullName < reposTemp[b].FullName
+	})
+
+	if f == nil {
+		return reposTemp, nil
+	}
+
+	// filter without the lock so f is free to call back into the DB
+	filtered := make([]Repo, 0)
+	for _, r := range reposTemp {
+		ok, err := f(r)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			filtered = append(filtered, r)
+		}
+	}
+
+	return filtered, nil
 }
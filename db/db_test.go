@@ -1,9 +1,13 @@
 package db
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
 	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/telemetry"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -102,3 +106,314 @@ func TestGetPullRequestByID(t *testing.T) {
 	assert.NoError(t, err, "Shuold be no error looking for PR")
 	assert.False(t, ok, "Should not be a PR back")
 }
+
+func TestGetFilterPullRequestsNilFilter(t *testing.T) {
+	db := &inMem{
+		pullRequests: []api.PullRequestData{{ID: 1234}, {ID: 4321}},
+		idIndex:      make(map[int]*api.PullRequestData),
+	}
+
+	prs, err := db.GetFilterPullRequests(nil)
+	assert.NoError(t, err, "Nil filter should not error")
+	assert.Equal(t, 2, len(prs), "Nil filter should match every PR")
+}
+
+func TestFiltersNilSafe(t *testing.T) {
+	merged := time.Now()
+	pr := api.PullRequestData{
+		ID:        1234,
+		State:     "closed",
+		MergedAt:  &merged,
+		Milestone: &api.MilestoneData{Title: "v1.0"},
+		Assignee:  &api.UserData{Login: "octocat"},
+		Head:      api.CommitData{Repo: &api.RepoData{FullName: "octocat/Hello-World"}},
+	}
+	// every optional field left nil, as decoded from a JSON null
+	pr2 := api.PullRequestData{
+		ID:    4321,
+		State: "open",
+	}
+	db := &inMem{
+		pullRequests: []api.PullRequestData{pr, pr2},
+		idIndex:      make(map[int]*api.PullRequestData),
+	}
+
+	filters := []PRFilterFunc{
+		FilterMerged(),
+		FilterMilestone("v1.0"),
+		FilterAssignee("octocat"),
+		FilterHeadRepo("octocat/Hello-World"),
+		FilterAnd(FilterState("closed"), nil, FilterMerged()),
+	}
+
+	for _, f := range filters {
+		prs, err := db.GetFilterPullRequests(f)
+		assert.NoError(t, err, "Should have no error filtering")
+		assert.Equal(t, 1, len(prs), "Only the populated PR should match")
+		assert.Equal(t, 1234, prs[0].ID, "Only the populated PR should match")
+	}
+
+	prs, err := db.GetFilterPullRequests(FilterState("open"))
+	assert.NoError(t, err, "Should have no error filtering")
+	assert.Equal(t, 4321, prs[0].ID, "Should match the open PR")
+}
+
+func TestFilterCIState(t *testing.T) {
+	db := &inMem{
+		pullRequests: []api.PullRequestData{{ID: 1234}, {ID: 4321}, {ID: 5678}},
+		idIndex:      make(map[int]*api.PullRequestData),
+		ciStates:     make(map[int]api.CIState),
+	}
+
+	assert.NoError(t, db.StoreCIState(1234, api.CIStateFailure))
+	assert.NoError(t, db.StoreCIState(4321, api.CIStateSuccess))
+
+	state, ok, err := db.GetCIState(1234)
+	assert.NoError(t, err, "Should be no error fetching CI state")
+	assert.True(t, ok, "Should have a CI state stored")
+	assert.Equal(t, api.CIStateFailure, state)
+
+	prs, err := db.GetFilterPullRequests(FilterCIState(db, api.CIStateFailure))
+	assert.NoError(t, err, "Should be no error filtering")
+	assert.Equal(t, 1, len(prs), "Should only match the failing PR")
+	assert.Equal(t, 1234, prs[0].ID)
+
+	prs, err = db.GetFilterPullRequests(FilterCIState(db, api.CIStateNone))
+	assert.NoError(t, err, "Should be no error filtering")
+	assert.Equal(t, 5678, prs[0].ID, "PR without CI state should match none")
+}
+
+func TestFilterClosesIssue(t *testing.T) {
+	db := &inMem{
+		pullRequests:  []api.PullRequestData{{ID: 1234}, {ID: 4321}},
+		idIndex:       make(map[int]*api.PullRequestData),
+		closingIssues: make(map[int][]api.IssueReference),
+	}
+
+	ref := api.IssueReference{Owner: "octocat", Repo: "Hello-World", Number: 45}
+	assert.NoError(t, db.StoreClosingIssues(1234, []api.IssueReference{ref}))
+
+	refs, ok, err := db.GetClosingIssues(1234)
+	assert.NoError(t, err, "Should be no error fetching closing issues")
+	assert.True(t, ok, "Should have closing issues stored")
+	assert.Equal(t, []api.IssueReference{ref}, refs)
+
+	prs, err := db.GetFilterPullRequests(FilterClosesIssue(db, ref))
+	assert.NoError(t, err, "Should be no error filtering")
+	assert.Equal(t, 1, len(prs), "Should only match the PR closing the issue")
+	assert.Equal(t, 1234, prs[0].ID)
+}
+
+func TestStoreUser(t *testing.T) {
+	db := &inMem{
+		users:      make(map[int]api.UserProfileData),
+		loginIndex: make(map[string]int),
+	}
+
+	u := api.UserProfileData{UserData: api.UserData{Login: "octocat", ID: 1}}
+	assert.NoError(t, db.StoreUser(u))
+
+	u.Login = "monalisa"
+	assert.NoError(t, db.StoreUser(u), "Should store renamed user")
+
+	uBack, ok, err := db.GetUserByLogin("monalisa")
+	assert.NoError(t, err, "Should be no error fetching user")
+	assert.True(t, ok, "Should find user by their new login")
+	assert.Equal(t, 1, uBack.ID)
+
+	_, ok, err = db.GetUserByLogin("octocat")
+	assert.NoError(t, err, "Should be no error fetching user")
+	assert.False(t, ok, "Old login should no longer be indexed")
+}
+
+func TestStoreUserLoginReused(t *testing.T) {
+	db := &inMem{
+		users:      make(map[int]api.UserProfileData),
+		loginIndex: make(map[string]int),
+	}
+
+	assert.NoError(t, db.StoreUser(api.UserProfileData{UserData: api.UserData{Login: "octocat", ID: 1}}))
+
+	// octocat is renamed, and someone else takes the login before we see it
+	assert.NoError(t, db.StoreUser(api.UserProfileData{UserData: api.UserData{Login: "octocat", ID: 2}}))
+	assert.NoError(t, db.StoreUser(api.UserProfileData{UserData: api.UserData{Login: "monalisa", ID: 1}}))
+
+	uBack, ok, err := db.GetUserByLogin("octocat")
+	assert.NoError(t, err, "Should be no error fetching user")
+	assert.True(t, ok, "Reused login should stay indexed")
+	assert.Equal(t, 2, uBack.ID)
+
+	uBack, ok, err = db.GetUserByLogin("monalisa")
+	assert.NoError(t, err, "Should be no error fetching user")
+	assert.True(t, ok, "Should find user by their new login")
+	assert.Equal(t, 1, uBack.ID)
+}
+
+func TestStorePullRequestUpsert(t *testing.T) {
+	db := &inMem{
+		pullRequests: make([]api.PullRequestData, 0),
+		idIndex:      make(map[int]*api.PullRequestData),
+	}
+
+	// enough PRs that the backing array is moved several times
+	for id := 0; id < 100; id++ {
+		assert.NoError(t, db.StorePullRequest(api.PullRequestData{ID: id, State: "open"}))
+	}
+	assert.NoError(t, db.StorePullRequest(api.PullRequestData{ID: 0, State: "closed"}))
+
+	assert.Equal(t, 100, len(db.pullRequests), "Storing a known ID should replace it")
+
+	pr, ok, err := db.GetPullRequestByID(0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "closed", pr.State, "Index should see the replaced PR")
+	assert.Equal(t, "closed", db.pullRequests[0].State, "Replaced PR should keep its position")
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	d, err := NewDB(&Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	reg := telemetry.NewRegistry()
+	RegisterMetrics(reg, d)
+
+	hello := &api.RepoData{FullName: "octocat/Hello-World"}
+	merged := time.Now()
+	assert.NoError(t, d.StorePullRequestBatch([]api.PullRequestData{
+		{ID: 1, State: "open", Base: api.CommitData{Repo: hello}},
+		{ID: 2, State: "open", Base: api.CommitData{Repo: hello}},
+		{ID: 3, State: "closed", MergedAt: &merged, ClosedAt: &merged, Base: api.CommitData{Repo: hello}},
+		{ID: 4, State: "closed"},
+	}))
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, reg.Write(buf))
+	assert.Contains(t, buf.String(), `gogitpr_db_pull_requests{state="open",repo="octocat/Hello-World"} 2`)
+	assert.Contains(t, buf.String(), `gogitpr_db_pull_requests{state="merged",repo="octocat/Hello-World"} 1`)
+	assert.Contains(t, buf.String(), `gogitpr_db_pull_requests{state="closed",repo=""} 1`)
+}
+
+func TestRequiredOwners(t *testing.T) {
+	db := &inMem{
+		requiredOwners: make(map[int][]string),
+	}
+
+	_, ok, err := db.GetRequiredOwners(1234)
+	assert.NoError(t, err)
+	assert.False(t, ok, "Owners shouldn't be stored until evaluated")
+
+	owners := []string{"@octocat", "@github/justice-league"}
+	assert.NoError(t, db.StoreRequiredOwners(1234, owners))
+	owners[0] = "@hubot"
+
+	stored, ok, err := db.GetRequiredOwners(1234)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"@octocat", "@github/justice-league"}, stored, "Stored owners should be a copy")
+}
+
+func TestStoreRepo(t *testing.T) {
+	db := &inMem{
+		syncedRepos:  make(map[int]Repo),
+		repoNames:    make(map[string]int),
+		oldRepoNames: make(map[string]int),
+	}
+
+	r := Repo{
+		RepoData:  api.RepoData{ID: 1296269, FullName: "octocat/Hello-World", Topics: []string{"octocat"}},
+		Languages: map[string]int{"Go": 4096, "Shell": 512},
+	}
+	assert.NoError(t, db.StoreRepo(r))
+	r.Topics[0] = "changed"
+
+	rBack, ok, err := db.GetRepo("OctoCat/hello-world")
+	assert.NoError(t, err)
+	assert.True(t, ok, "Repos should be found ignoring case")
+	assert.Equal(t, []string{"octocat"}, rBack.Topics, "Stored repo should be a copy")
+	assert.False(t, rBack.Renamed())
+
+	renamed := Repo{RepoData: api.RepoData{ID: 1296269, FullName: "github/hello-world"}}
+	assert.NoError(t, db.StoreRepo(renamed), "Should store renamed repo")
+
+	rBack, ok, err = db.GetRepo("octocat/Hello-World")
+	assert.NoError(t, err)
+	assert.True(t, ok, "Should find repo by its previous name")
+	assert.Equal(t, "github/hello-world", rBack.FullName)
+	assert.Equal(t, []string{"octocat/Hello-World"}, rBack.PreviousNames)
+	assert.Equal(t, map[string]int{"Go": 4096, "Shell": 512}, rBack.Languages, "Languages should be kept unless given")
+
+	// a new repo taking the old name is found by it instead
+	assert.NoError(t, db.StoreRepo(Repo{RepoData: api.RepoData{ID: 1, FullName: "octocat/Hello-World"}}))
+	rBack, _, _ = db.GetRepo("octocat/Hello-World")
+	assert.Equal(t, 1, rBack.ID)
+
+	// renaming back drops the name from the previous names
+	assert.NoError(t, db.StoreRepo(Repo{RepoData: api.RepoData{ID: 1296269, FullName: "octocat/Hello-World"}}))
+	rBack, _, _ = db.GetRepo("github/hello-world")
+	assert.Equal(t, []string{"github/hello-world"}, rBack.PreviousNames)
+
+	_, ok, err = db.GetRepo("octocat/Spoon-Knife")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestStoreRepoSwappedNames(t *testing.T) {
+	db := &inMem{
+		syncedRepos:  make(map[int]Repo),
+		repoNames:    make(map[string]int),
+		oldRepoNames: make(map[string]int),
+	}
+
+	assert.NoError(t, db.StoreRepo(Repo{RepoData: api.RepoData{ID: 1, FullName: "octocat/a"}}))
+	assert.NoError(t, db.StoreRepo(Repo{RepoData: api.RepoData{ID: 2, FullName: "octocat/b"}}))
+
+	// each repo takes the other's name
+	assert.NoError(t, db.StoreRepo(Repo{RepoData: api.RepoData{ID: 1, FullName: "octocat/b"}}))
+	assert.NoError(t, db.StoreRepo(Repo{RepoData: api.RepoData{ID: 2, FullName: "octocat/a"}}))
+
+	rBack, ok, err := db.GetRepo("octocat/a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, rBack.ID)
+
+	rBack, ok, err = db.GetRepo("octocat/b")
+	assert.NoError(t, err)
+	assert.True(t, ok, "Name should stay with the repo which took it")
+	assert.Equal(t, 1, rBack.ID)
+}
+
+func TestListRepos(t *testing.T) {
+	d, err := NewDB(&Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	assert.NoError(t, d.StoreRepo(Repo{
+		RepoData:  api.RepoData{ID: 3, FullName: "octocat/linguist", Owner: api.UserData{Login: "octocat"}, Topics: []string{"languages"}},
+		Languages: map[string]int{"Ruby": 8192, "Go": 16},
+	}))
+	assert.NoError(t, d.StoreRepo(Repo{
+		RepoData: api.RepoData{ID: 2, FullName: "github/docs", Owner: api.UserData{Login: "github"}, Archived: true, Language: "JavaScript"},
+		Missing:  true,
+	}))
+	assert.NoError(t, d.StoreRepo(Repo{
+		RepoData: api.RepoData{ID: 1, FullName: "octocat/Spoon-Knife", Owner: api.UserData{Login: "octocat"}, Fork: true, Language: "HTML"},
+	}))
+
+	names := func(f RepoFilterFunc) []string {
+		repos, err := d.ListRepos(f)
+		assert.NoError(t, err)
+		names := make([]string, 0)
+		for _, r := range repos {
+			names = append(names, r.FullName)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"github/docs", "octocat/Spoon-Knife", "octocat/linguist"}, names(nil), "Repos should be ordered by full name")
+	assert.Equal(t, []string{"github/docs"}, names(FilterReposArchived(true)))
+	assert.Equal(t, []string{"octocat/Spoon-Knife", "octocat/linguist"}, names(FilterReposMissing(false)))
+	assert.Equal(t, []string{"octocat/linguist"}, names(FilterReposAnd(FilterReposOwner("OctoCat"), FilterReposFork(false), nil)))
+	assert.Equal(t, []string{"octocat/linguist"}, names(FilterReposLanguage("go")))
+	assert.Equal(t, []string{"github/docs"}, names(FilterReposLanguage("JavaScript")), "Primary language should match without languages")
+	assert.Equal(t, []string{"octocat/linguist"}, names(FilterReposTopic("languages")))
+	assert.Equal(t, []string{}, names(FilterReposRenamed()))
+}
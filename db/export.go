@@ -0,0 +1,373 @@
+package db
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ExportFormat identifies a gogitpr export
+	ExportFormat = "gogitpr"
+	// SchemaVersion is the version of the records written by Export. It is
+	// bumped whenever a record changes incompatibly, and Import migrates
+	// older versions
+	SchemaVersion = 1
+)
+
+// Kinds of record in an export, in the order they are written
+const (
+	KindHeader      = "header"
+	KindRepo        = "repo"
+	KindUser        = "user"
+	KindTeam        = "team"
+	KindMilestone   = "milestone"
+	KindPullRequest = "pull_request"
+)
+
+// Header describes an export. It is the first line of an NDJSON export, and
+// the manifest of an archive
+type Header struct {
+	Format        string    `json:"format"`
+	SchemaVersion int       `json:"schema_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	// Counts holds the number of records of each kind
+	Counts map[string]int `json:"counts"`
+}
+
+func (h *Header) validate() error {
+	if h.Format != ExportFormat {
+		return fmt.Errorf("not a %s export, format is %q", ExportFormat, h.Format)
+	}
+
+	if h.SchemaVersion < 1 || h.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("unsupported schema version %d, expected at most %d", h.SchemaVersion, SchemaVersion)
+	}
+
+	return nil
+}
+
+// Record is a single line of an NDJSON export
+type Record struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// TeamRecord is the data of a team record
+type TeamRecord struct {
+	Team      api.TeamData `json:"team"`
+	MemberIDs []int        `json:"member_ids"`
+}
+
+// PullRequestRecord is the data of a pull request record, along with what is
+// stored against the pull request
+type PullRequestRecord struct {
+	PullRequest   api.PullRequestData  `json:"pull_request"`
+	CIState       *api.CIState         `json:"ci_state,omitempty"`
+	ClosingIssues []api.IssueReference `json:"closing_issues"`
+	Reviews       []api.ReviewData     `json:"reviews,omitempty"`
+	// RequiredOwners is nil unless the PR's code owners were evaluated
+	RequiredOwners []string `json:"required_owners"`
+}
+
+// snapshot is everything read from a DB for export
+type snapshot struct {
+	repos        []Repo
+	users        []api.UserProfileData
+	teams        []TeamRecord
+	milestones   []api.MilestoneData
+	pullRequests []PullRequestRecord
+}
+
+func (s *snapshot) header() *Header {
+	return &Header{
+		Format:        ExportFormat,
+		SchemaVersion: SchemaVersion,
+		ExportedAt:    time.Now().UTC(),
+		Counts: map[string]int{
+			KindRepo:        len(s.repos),
+			KindUser:        len(s.users),
+			KindTeam:        len(s.teams),
+			KindMilestone:   len(s.milestones),
+			KindPullRequest: len(s.pullRequests),
+		},
+	}
+}
+
+// records calls f with the kind and data of every record, in order
+func (s *snapshot) records(f func(kind string, data interface{}) error) error {
+	for _, r := range s.repos {
+		if err := f(KindRepo, r); err != nil {
+			return err
+		}
+	}
+	for _, u := range s.users {
+		if err := f(KindUser, u); err != nil {
+			return err
+		}
+	}
+	for _, t := range s.teams {
+		if err := f(KindTeam, t); err != nil {
+			return err
+		}
+	}
+	for _, m := range s.milestones {
+		if err := f(KindMilestone, m); err != nil {
+			return err
+		}
+	}
+	for _, pr := range s.pullRequests {
+		if err := f(KindPullRequest, pr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readSnapshot(d DB) (*snapshot, error) {
+	s := new(snapshot)
+
+	prs, err := d.GetAllPullRequests()
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make(map[int]Repo)
+	for _, pr := range prs {
+		// repos which were never synced are only stored within the PRs
+		// referencing them
+		for _, c := range []api.CommitData{pr.Base, pr.Head} {
+			if c.HasRepo() {
+				repos[c.Repo.ID] = Repo{RepoData: *c.Repo}
+			}
+		}
+
+		record := PullRequestRecord{PullRequest: pr}
+
+		state, ok, err := d.GetCIState(pr.ID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			record.CIState = &state
+		}
+
+		if record.ClosingIssues, _, err = d.GetClosingIssues(pr.ID); err != nil {
+			return nil, err
+		}
+
+		if record.Reviews, err = d.GetReviews(pr.ID); err != nil {
+			return nil, err
+		}
+
+		if record.RequiredOwners, _, err = d.GetRequiredOwners(pr.ID); err != nil {
+			return nil, err
+		}
+
+		s.pullRequests = append(s.pullRequests, record)
+	}
+
+	synced, err := d.ListRepos(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range synced {
+		repos[r.ID] = r
+	}
+
+	for _, r := range repos {
+		s.repos = append(s.repos, r)
+	}
+	sort.Slice(s.repos, func(a, b int) bool {
+		return s.repos[a].ID < s.repos[b].ID
+	})
+
+	if s.users, err = d.GetAllUsers(); err != nil {
+		return nil, err
+	}
+
+	teams, err := d.GetAllTeams()
+	if err != nil {
+		return nil, err
+	}
+	for _, team := range teams {
+		ids, err := d.GetTeamMemberIDs(team.ID)
+		if err != nil {
+			return nil, err
+		}
+		s.teams = append(s.teams, TeamRecord{Team: team, MemberIDs: ids})
+	}
+
+	if s.milestones, err = d.GetAllMilestones(); err != nil {
+		return nil, err
+	}
+	sort.Slice(s.milestones, func(a, b int) bool {
+		return s.milestones[a].ID < s.milestones[b].ID
+	})
+
+	return s, nil
+}
+
+// Export writes everything stored in d to w as NDJSON: a header record
+// followed by a record per repo, user, team, milestone and pull request
+func Export(d DB, w io.Writer) error {
+	s, err := readSnapshot(d)
+	if err != nil {
+		return errors.Wrap(err, "reading DB")
+	}
+
+	enc := json.NewEncoder(w)
+	write := func(kind string, data interface{}) error {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		return enc.Encode(Record{Kind: kind, Data: raw})
+	}
+
+	if err := write(KindHeader, s.header()); err != nil {
+		return err
+	}
+
+	return s.records(write)
+}
+
+// Import stores every record of the NDJSON export read from r in d, replacing
+// whatever d already holds with the same IDs. It returns the header of the
+// export
+func Import(d DB, r io.Reader) (*Header, error) {
+	scanner := bufio.NewScanner(r)
+	// pull requests with long bodies easily exceed the default line limit
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	var header *Header
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+
+		if header == nil {
+			if record.Kind != KindHeader {
+				return nil, fmt.Errorf("line %d: expected a %s record, got %q", line, KindHeader, record.Kind)
+			}
+
+			header = new(Header)
+			if err := json.Unmarshal(record.Data, header); err != nil {
+				return nil, errors.Wrapf(err, "line %d", line)
+			}
+			if err := header.validate(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := importRecord(d, record.Kind, record.Data); err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if header == nil {
+		return nil, fmt.Errorf("export is empty")
+	}
+
+	return header, nil
+}
+
+func importRecord(d DB, kind string, data json.RawMessage) error {
+	switch kind {
+	case KindRepo:
+		var r Repo
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+		if r.SyncedAt.IsZero() {
+			// the repo was never synced, so is only stored within the PRs
+			// referencing it and restored by the pull request records
+			return nil
+		}
+		return d.StoreRepo(r)
+	case KindUser:
+		var u api.UserProfileData
+		if err := json.Unmarshal(data, &u); err != nil {
+			return err
+		}
+		return d.StoreUser(u)
+	case KindTeam:
+		var t TeamRecord
+		if err := json.Unmarshal(data, &t); err != nil {
+			return err
+		}
+		members := make([]api.UserData, 0, len(t.MemberIDs))
+		for _, id := range t.MemberIDs {
+			members = append(members, api.UserData{ID: id})
+		}
+		return d.StoreTeam(t.Team, members)
+	case KindMilestone:
+		var m api.MilestoneData
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		return d.StoreMilestone(m)
+	case KindPullRequest:
+		var pr PullRequestRecord
+		if err := json.Unmarshal(data, &pr); err != nil {
+			return err
+		}
+		return importPullRequest(d, pr)
+	default:
+		return fmt.Errorf("unknown record kind %q", kind)
+	}
+}
+
+func importPullRequest(d DB, pr PullRequestRecord) error {
+	id := pr.PullRequest.ID
+
+	if err := d.StorePullRequest(pr.PullRequest); err != nil {
+		return err
+	}
+
+	if pr.CIState != nil {
+		if err := d.StoreCIState(id, *pr.CIState); err != nil {
+			return err
+		}
+	}
+
+	if pr.ClosingIssues != nil {
+		if err := d.StoreClosingIssues(id, pr.ClosingIssues); err != nil {
+			return err
+		}
+	}
+
+	for _, review := range pr.Reviews {
+		if err := d.StoreReview(id, review); err != nil {
+			return err
+		}
+	}
+
+	if pr.RequiredOwners != nil {
+		if err := d.StoreRequiredOwners(id, pr.RequiredOwners); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
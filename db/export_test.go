@@ -0,0 +1,168 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newExportTestDB(t *testing.T) DB {
+	d, err := NewDB(&Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	hello := &api.RepoData{ID: 1296269, Name: "Hello-World", FullName: "octocat/Hello-World"}
+	fork := &api.RepoData{ID: 1296270, Name: "Hello-World", FullName: "hubot/Hello-World", Fork: true}
+	octocat := api.UserData{ID: 1, Login: "octocat"}
+	hubot := api.UserData{ID: 2, Login: "hubot"}
+	v1 := api.MilestoneData{ID: 1002604, Number: 1, Title: "v1.0", State: "open"}
+	submitted := time.Date(2011, 1, 26, 19, 1, 12, 0, time.UTC)
+
+	assert.NoError(t, d.StorePullRequestBatch([]api.PullRequestData{
+		{ID: 1, Number: 1347, State: "open", Title: "new-feature", User: hubot, Milestone: &v1,
+			Base: api.CommitData{Ref: "master", Repo: hello}, Head: api.CommitData{Ref: "new-topic", Repo: fork}},
+		{ID: 2, Number: 1348, State: "closed", User: octocat, Base: api.CommitData{Repo: hello}},
+	}))
+	assert.NoError(t, d.StoreCIState(1, api.CIStateSuccess))
+	assert.NoError(t, d.StoreClosingIssues(1, []api.IssueReference{{Owner: "octocat", Repo: "Hello-World", Number: 1}}))
+	assert.NoError(t, d.StoreClosingIssues(2, []api.IssueReference{}))
+	assert.NoError(t, d.StoreReview(1, api.ReviewData{ID: 80, User: octocat, State: "APPROVED", SubmittedAt: &submitted}))
+	assert.NoError(t, d.StoreRequiredOwners(1, []string{"@github/justice-league", "@octocat"}))
+	assert.NoError(t, d.StoreRequiredOwners(2, []string{}))
+	assert.NoError(t, d.StoreMilestone(v1))
+	assert.NoError(t, d.StoreUser(api.UserProfileData{UserData: octocat, Name: "The Octocat"}))
+	assert.NoError(t, d.StoreUser(api.UserProfileData{UserData: hubot}))
+	assert.NoError(t, d.StoreTeam(api.TeamData{ID: 1, Slug: "justice-league"}, []api.UserData{octocat, hubot}))
+	assert.NoError(t, d.StoreRepo(Repo{RepoData: *hello, Languages: map[string]int{"Go": 4096}, SyncedAt: submitted}))
+	assert.NoError(t, d.StoreRepo(Repo{
+		RepoData: api.RepoData{ID: 1300192, Name: "Spoon-Knife", FullName: "octocat/Spoon-Knife", Archived: true},
+		SyncedAt: submitted, Missing: true, PreviousNames: []string{"octocat/Spoon"},
+	}))
+
+	return d
+}
+
+// assertSameDB checks everything stored in want is stored in got
+func assertSameDB(t *testing.T, want, got DB) {
+	wantPRs, _ := want.GetAllPullRequests()
+	gotPRs, _ := got.GetAllPullRequests()
+	assert.Equal(t, wantPRs, gotPRs)
+
+	for _, pr := range wantPRs {
+		wantState, wantOK, _ := want.GetCIState(pr.ID)
+		gotState, gotOK, _ := got.GetCIState(pr.ID)
+		assert.Equal(t, wantOK, gotOK)
+		assert.Equal(t, wantState, gotState)
+
+		wantRefs, wantOK, _ := want.GetClosingIssues(pr.ID)
+		gotRefs, gotOK, _ := got.GetClosingIssues(pr.ID)
+		assert.Equal(t, wantOK, gotOK)
+		assert.Equal(t, wantRefs, gotRefs)
+
+		wantReviews, _ := want.GetReviews(pr.ID)
+		gotReviews, _ := got.GetReviews(pr.ID)
+		assert.Equal(t, wantReviews, gotReviews)
+
+		wantOwners, wantOK, _ := want.GetRequiredOwners(pr.ID)
+		gotOwners, gotOK, _ := got.GetRequiredOwners(pr.ID)
+		assert.Equal(t, wantOK, gotOK)
+		assert.Equal(t, wantOwners, gotOwners)
+	}
+
+	wantMilestones, _ := want.GetAllMilestones()
+	gotMilestones, _ := got.GetAllMilestones()
+	assert.Equal(t, wantMilestones, gotMilestones)
+
+	wantUsers, _ := want.GetAllUsers()
+	gotUsers, _ := got.GetAllUsers()
+	assert.Equal(t, wantUsers, gotUsers)
+
+	wantTeams, _ := want.GetAllTeams()
+	gotTeams, _ := got.GetAllTeams()
+	assert.Equal(t, wantTeams, gotTeams)
+	for _, team := range wantTeams {
+		wantIDs, _ := want.GetTeamMemberIDs(team.ID)
+		gotIDs, _ := got.GetTeamMemberIDs(team.ID)
+		assert.Equal(t, wantIDs, gotIDs)
+	}
+
+	wantRepos, _ := want.ListRepos(nil)
+	gotRepos, _ := got.ListRepos(nil)
+	assert.Equal(t, wantRepos, gotRepos)
+}
+
+func TestExportImport(t *testing.T) {
+	d := newExportTestDB(t)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, Export(d, buf), "Should export DB")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 1+3+2+1+1+2, len(lines), "Should write a header and a line per record")
+
+	var first Record
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, KindHeader, first.Kind)
+
+	imported, err := NewDB(&Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	header, err := Import(imported, bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err, "Should import export")
+	assert.Equal(t, SchemaVersion, header.SchemaVersion)
+	assert.Equal(t, 3, header.Counts[KindRepo], "Synced, head and base repos should all be exported")
+	assert.Equal(t, 2, header.Counts[KindPullRequest])
+
+	assertSameDB(t, d, imported)
+
+	// importing again replaces rather than duplicates
+	_, err = Import(imported, bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assertSameDB(t, d, imported)
+}
+
+func TestExportImportArchive(t *testing.T) {
+	d := newExportTestDB(t)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, ExportArchive(d, buf), "Should export archive")
+
+	imported, err := NewDB(&Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	header, err := ImportArchive(imported, buf)
+	assert.NoError(t, err, "Should import archive")
+	assert.Equal(t, ExportFormat, header.Format)
+	assert.Equal(t, 2, header.Counts[KindUser])
+
+	assertSameDB(t, d, imported)
+
+	_, err = ImportArchive(imported, strings.NewReader("not gzip"))
+	assert.Error(t, err)
+}
+
+func TestImportInvalid(t *testing.T) {
+	d, err := NewDB(&Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	_, err = Import(d, strings.NewReader(""))
+	assert.EqualError(t, err, "export is empty")
+
+	_, err = Import(d, strings.NewReader(`{"kind": "user", "data": {"id": 1}}`))
+	assert.EqualError(t, err, `line 1: expected a header record, got "user"`)
+
+	_, err = Import(d, strings.NewReader(`{"kind": "header", "data": {"format": "other", "schema_version": 1}}`))
+	assert.EqualError(t, err, `not a gogitpr export, format is "other"`)
+
+	_, err = Import(d, strings.NewReader(`{"kind": "header", "data": {"format": "gogitpr", "schema_version": 2}}`))
+	assert.EqualError(t, err, "unsupported schema version 2, expected at most 1")
+
+	_, err = Import(d, strings.NewReader(`{"kind": "header", "data": {"format": "gogitpr", "schema_version": 1}}
+{"kind": "label", "data": {}}`))
+	assert.EqualError(t, err, `line 2: unknown record kind "label"`)
+}
@@ -0,0 +1,195 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/doodles526/gogitpr/api"
+)
+
+// FilterState returns PRs whose state (open/closed) matches state
+func FilterState(state string) PRFilterFunc {
+	return func(pr api.PullRequestData) (bool, error) {
+		return pr.State == state, nil
+	}
+}
+
+// FilterMerged returns PRs which have been merged
+func FilterMerged() PRFilterFunc {
+	return func(pr api.PullRequestData) (bool, error) {
+		return pr.IsMerged(), nil
+	}
+}
+
+// FilterMilestone returns PRs attached to the milestone with the given title.
+// PRs without a milestone never match
+func FilterMilestone(title string) PRFilterFunc {
+	return func(pr api.PullRequestData) (bool, error) {
+		return pr.HasMilestone() && pr.Milestone.Title == title, nil
+	}
+}
+
+// FilterAssignee returns PRs assigned to login. Unassigned PRs never match
+func FilterAssignee(login string) PRFilterFunc {
+	return func(pr api.PullRequestData) (bool, error) {
+		return pr.HasAssignee() && pr.Assignee.Login == login, nil
+	}
+}
+
+// FilterHeadRepo returns PRs whose head is in the repo with the given full
+// name. PRs from deleted forks never match
+func FilterHeadRepo(fullName string) PRFilterFunc {
+	return func(pr api.PullRequestData) (bool, error) {
+		return pr.Head.HasRepo() && pr.Head.Repo.FullName == fullName, nil
+	}
+}
+
+// FilterRepo returns PRs opened against the repo with the given full name
+func FilterRepo(fullName string) PRFilterFunc {
+	return func(pr api.PullRequestData) (bool, error) {
+		return pr.Base.HasRepo() && pr.Base.Repo.FullName == fullName, nil
+	}
+}
+
+// FilterAuthor returns PRs opened by login
+func FilterAuthor(login string) PRFilterFunc {
+	return func(pr api.PullRequestData) (bool, error) {
+		return pr.User.Login == login, nil
+	}
+}
+
+// FilterBaseOwner returns PRs opened against a repo owned by the given user or
+// org
+func FilterBaseOwner(owner string) PRFilterFunc {
+	return func(pr api.PullRequestData) (bool, error) {
+		return pr.Base.HasRepo() && pr.Base.Repo.Owner.Login == owner, nil
+	}
+}
+
+// FilterCIState returns PRs whose CI state stored in d matches state. PRs
+// without a stored CI state only match api.CIStateNone
+func FilterCIState(d DB, state api.CIState) PRFilterFunc {
+	return func(pr api.PullRequestData) (bool, error) {
+		prState, _, err := d.GetCIState(pr.ID)
+		if err != nil {
+			return false, err
+		}
+
+		return prState == state, nil
+	}
+}
+
+// FilterClosesIssue returns PRs which the closing issues stored in d say
+// close ref
+func FilterClosesIssue(d DB, ref api.IssueReference) PRFilterFunc {
+	return func(pr api.PullRequestData) (bool, error) {
+		refs, _, err := d.GetClosingIssues(pr.ID)
+		if err != nil {
+			return false, err
+		}
+
+		for _, r := range refs {
+			if r == ref {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+}
+
+// FilterAnd returns PRs which match every filter given. Nil filters are ignored
+func FilterAnd(filters ...PRFilterFunc) PRFilterFunc {
+	return func(pr api.PullRequestData) (bool, error) {
+		for _, f := range filters {
+			if f == nil {
+				continue
+			}
+			ok, err := f(pr)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+
+		return true, nil
+	}
+}
+
+// FilterReposArchived returns repos which are archived, or which aren't
+func FilterReposArchived(archived bool) RepoFilterFunc {
+	return func(r Repo) (bool, error) {
+		return r.Archived == archived, nil
+	}
+}
+
+// FilterReposFork returns repos which are forks, or which aren't
+func FilterReposFork(fork bool) RepoFilterFunc {
+	return func(r Repo) (bool, error) {
+		return r.Fork == fork, nil
+	}
+}
+
+// FilterReposMissing returns repos which the last sync of their owner didn't
+// find, or which it did
+func FilterReposMissing(missing bool) RepoFilterFunc {
+	return func(r Repo) (bool, error) {
+		return r.Missing == missing, nil
+	}
+}
+
+// FilterReposRenamed returns repos stored under another name before
+func FilterReposRenamed() RepoFilterFunc {
+	return func(r Repo) (bool, error) {
+		return r.Renamed(), nil
+	}
+}
+
+// FilterReposOwner returns repos owned by the given user or org
+func FilterReposOwner(owner string) RepoFilterFunc {
+	return func(r Repo) (bool, error) {
+		return strings.EqualFold(r.Owner.Login, owner), nil
+	}
+}
+
+// FilterReposTopic returns repos tagged with topic
+func FilterReposTopic(topic string) RepoFilterFunc {
+	return func(r Repo) (bool, error) {
+		return r.HasTopic(topic), nil
+	}
+}
+
+// FilterReposLanguage returns repos with any code in lang. Repos whose
+// languages weren't fetched only match their primary language
+func FilterReposLanguage(lang string) RepoFilterFunc {
+	return func(r Repo) (bool, error) {
+		if r.Languages == nil {
+			primary, _ := r.Language.(string)
+			return strings.EqualFold(primary, lang), nil
+		}
+
+		for l := range r.Languages {
+			if strings.EqualFold(l, lang) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+}
+
+// FilterReposAnd returns repos which match every filter given. Nil filters
+// are ignored
+func FilterReposAnd(filters ...RepoFilterFunc) RepoFilterFunc {
+	return func(r Repo) (bool, error) {
+		for _, f := range filters {
+			if f == nil {
+				continue
+			}
+			ok, err := f(r)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+
+		return true, nil
+	}
+}
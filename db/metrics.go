@@ -0,0 +1,44 @@
+package db
+
+import (
+	"github.com/doodles526/gogitpr/telemetry"
+)
+
+// RegisterMetrics registers gauges in reg of the PRs stored in d by state and
+// base repo, counted afresh on every scrape. The state of merged PRs is
+// reported as "merged" rather than "closed"
+func RegisterMetrics(reg *telemetry.Registry, d DB) {
+	stored := reg.NewGauge("gogitpr_db_pull_requests",
+		"Pull requests stored, by state and base repo.", "state", "repo")
+
+	reg.OnCollect(func() error {
+		prs, err := d.GetAllPullRequests()
+		if err != nil {
+			return err
+		}
+
+		type key struct{ state, repo string }
+		counts := make(map[key]int)
+		for _, pr := range prs {
+			state := pr.State
+			if pr.IsMerged() {
+				state = "merged"
+			}
+
+			repo := ""
+			if pr.Base.HasRepo() {
+				repo = pr.Base.Repo.FullName
+			}
+
+			counts[key{state, repo}]++
+		}
+
+		// reset so repos no longer stored stop being reported
+		stored.Reset()
+		for k, count := range counts {
+			stored.Set(float64(count), k.state, k.repo)
+		}
+
+		return nil
+	})
+}
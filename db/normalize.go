@@ -0,0 +1,109 @@
+package db
+
+import (
+	"github.com/doodles526/gogitpr/api"
+)
+
+// PRs share their users and repos with many others, so inMem stores each
+// user and repo once, keyed by ID, and PRs only keep the IDs of those they
+// reference. A user or repo without an ID can't be shared, so is kept within
+// the PR as is. Reading a PR returns the latest copy stored of each user and
+// repo it references
+
+// userRef is a user normalized out of a PR. Only the ID is kept, which
+// distinguishes it from a user without an ID left in place
+func (i *inMem) userRef(u api.UserData) api.UserData {
+	if u.ID == 0 {
+		return u
+	}
+	i.prUsers[u.ID] = u
+
+	return api.UserData{ID: u.ID}
+}
+
+// user reverses userRef
+func (i *inMem) user(ref api.UserData) api.UserData {
+	if ref.ID == 0 {
+		return ref
+	}
+
+	if u, ok := i.prUsers[ref.ID]; ok {
+		return u
+	}
+
+	return ref
+}
+
+// repoRef is a repo normalized out of a PR
+func (i *inMem) repoRef(r *api.RepoData) *api.RepoData {
+	if r == nil || r.ID == 0 {
+		return r
+	}
+	i.repos[r.ID] = *r
+
+	return &api.RepoData{ID: r.ID}
+}
+
+// repo reverses repoRef, returning a copy so callers can't modify the store
+func (i *inMem) repo(ref *api.RepoData) *api.RepoData {
+	if ref == nil {
+		return nil
+	}
+
+	r, ok := i.repos[ref.ID]
+	if !ok {
+		// a repo without an ID was left in place
+		r = *ref
+	}
+
+	return &r
+}
+
+// normalize returns pr with every user and repo it references replaced by a
+// ref, storing them
+func (i *inMem) normalize(pr api.PullRequestData) api.PullRequestData {
+	pr.User = i.userRef(pr.User)
+	if pr.Assignee != nil {
+		assignee := i.userRef(*pr.Assignee)
+		pr.Assignee = &assignee
+	}
+
+	if pr.RequestedReviewers != nil {
+		reviewers := make([]api.UserData, 0, len(pr.RequestedReviewers))
+		for _, u := range pr.RequestedReviewers {
+			reviewers = append(reviewers, i.userRef(u))
+		}
+		pr.RequestedReviewers = reviewers
+	}
+
+	pr.Head.User = i.userRef(pr.Head.User)
+	pr.Head.Repo = i.repoRef(pr.Head.Repo)
+	pr.Base.User = i.userRef(pr.Base.User)
+	pr.Base.Repo = i.repoRef(pr.Base.Repo)
+
+	return pr
+}
+
+// denormalize reverses normalize
+func (i *inMem) denormalize(pr api.PullRequestData) api.PullRequestData {
+	pr.User = i.user(pr.User)
+	if pr.Assignee != nil {
+		assignee := i.user(*pr.Assignee)
+		pr.Assignee = &assignee
+	}
+
+	if pr.RequestedReviewers != nil {
+		reviewers := make([]api.UserData, 0, len(pr.RequestedReviewers))
+		for _, u := range pr.RequestedReviewers {
+			reviewers = append(reviewers, i.user(u))
+		}
+		pr.RequestedReviewers = reviewers
+	}
+
+	pr.Head.User = i.user(pr.Head.User)
+	pr.Head.Repo = i.repo(pr.Head.Repo)
+	pr.Base.User = i.user(pr.Base.User)
+	pr.Base.Repo = i.repo(pr.Base.Repo)
+
+	return pr
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"runtime"
+	"testing"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadFixture(t testing.TB) []byte {
+	fixture, err := ioutil.ReadFile("testdata/pull_request.json")
+	assert.NoError(t, err)
+
+	return fixture
+}
+
+func decodeFixture(t testing.TB, fixture []byte, id int) api.PullRequestData {
+	var pr api.PullRequestData
+	assert.NoError(t, json.Unmarshal(fixture, &pr))
+	pr.ID = id
+	pr.Number = id
+
+	return pr
+}
+
+func TestNormalize(t *testing.T) {
+	fixture := loadFixture(t)
+	d, err := NewDB(&Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+	i := d.(*inMem)
+
+	pr := decodeFixture(t, fixture, 1)
+	pr.RequestedReviewers = []api.UserData{pr.User, {Login: "no-id"}}
+	assert.NoError(t, d.StorePullRequestBatch([]api.PullRequestData{pr, decodeFixture(t, fixture, 2)}))
+
+	assert.Equal(t, 1, len(i.repos), "Head and base repos of both PRs should be stored once")
+	assert.Equal(t, 1, len(i.prUsers), "Every user of both PRs should be stored once")
+	assert.Equal(t, api.UserData{ID: pr.User.ID}, i.pullRequests[0].User, "Stored PRs should only reference users")
+
+	got, ok, err := d.GetPullRequestByID(1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, pr, got, "Reading should reconstruct the PR stored")
+
+	got, ok, err = d.GetPullRequestByNumber("octocat/Hello-World", 2)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "octocat", got.User.Login)
+
+	// a reconstructed PR must not share its repos with the store
+	got.Base.Repo.FullName = "modified"
+	prs, err := d.GetAllPullRequests()
+	assert.NoError(t, err)
+	assert.Equal(t, "octocat/Hello-World", prs[1].Base.Repo.FullName)
+	assert.Equal(t, pr, prs[0])
+
+	// the latest copy of a repo is returned for every PR referencing it
+	renamed := decodeFixture(t, fixture, 3)
+	renamed.Base.Repo.FullName = "octocat/Hello-Universe"
+	assert.NoError(t, d.StorePullRequest(renamed))
+	got, _, _ = d.GetPullRequestByID(1)
+	assert.Equal(t, "octocat/Hello-Universe", got.Base.Repo.FullName)
+}
+
+// BenchmarkMemoryPerPR reports the heap used per PR stored, comparing PRs kept
+// whole as decoded from github with those stored normalized by inMem
+func BenchmarkMemoryPerPR(b *testing.B) {
+	const n = 1000
+	fixture := loadFixture(b)
+
+	measure := func(b *testing.B, store func() interface{}) {
+		var total uint64
+		for iter := 0; iter < b.N; iter++ {
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+
+			stored := store()
+
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			runtime.KeepAlive(stored)
+
+			if after.HeapAlloc > before.HeapAlloc {
+				total += after.HeapAlloc - before.HeapAlloc
+			}
+		}
+		b.ReportMetric(float64(total)/float64(b.N)/n, "B/pr")
+	}
+
+	b.Run("whole", func(b *testing.B) {
+		measure(b, func() interface{} {
+			prs := make([]api.PullRequestData, 0, n)
+			for id := 1; id <= n; id++ {
+				prs = append(prs, decodeFixture(b, fixture, id))
+			}
+			return prs
+		})
+	})
+
+	b.Run("normalized", func(b *testing.B) {
+		measure(b, func() interface{} {
+			d, _ := NewDB(&Args{Logger: logrus.New()})
+			for id := 1; id <= n; id++ {
+				d.StorePullRequest(decodeFixture(b, fixture, id))
+			}
+			return d
+		})
+	})
+}
@@ -0,0 +1,174 @@
+// Package diff parses unified diffs, such as those github returns for pull
+// requests, into the files, hunks and lines they change
+package diff
+
+import (
+	"path"
+	"strings"
+)
+
+// FileStatus is how a diff changes a file
+type FileStatus string
+
+const (
+	// Added files are created by the diff
+	Added FileStatus = "added"
+	// Deleted files are removed by the diff
+	Deleted FileStatus = "deleted"
+	// Modified files have their content changed
+	Modified FileStatus = "modified"
+	// Renamed files are moved, and may have their content changed too
+	Renamed FileStatus = "renamed"
+)
+
+// File is the change to a single file
+type File struct {
+	// OldName is blank for added files, NewName for deleted files
+	OldName string
+	NewName string
+	Status  FileStatus
+	// Binary files have no hunks
+	Binary bool
+	Hunks  []Hunk
+}
+
+// Name is the name of the file after the diff, or before it for deleted files
+func (f *File) Name() string {
+	if f.Status == Deleted {
+		return f.OldName
+	}
+
+	return f.NewName
+}
+
+// Hunk is a contiguous run of changed lines, with the context around them
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Section is the text following the hunk header, usually the function
+	// the hunk is in
+	Section string
+	Lines   []Line
+}
+
+// LineKind is how a line of a hunk is changed
+type LineKind int
+
+const (
+	// Context lines are unchanged
+	Context LineKind = iota
+	// AddedLine lines are only in the new file
+	AddedLine
+	// DeletedLine lines are only in the old file
+	DeletedLine
+)
+
+// Line is a single line of a hunk
+type Line struct {
+	Kind    LineKind
+	Content string
+	// OldNumber and NewNumber are the line's number in the old and new file,
+	// zero for added and deleted lines respectively
+	OldNumber int
+	NewNumber int
+	// NoNewline is set on the last line of a file lacking a trailing newline
+	NoNewline bool
+}
+
+// Range is an inclusive range of line numbers
+type Range struct {
+	Start int
+	End   int
+}
+
+// AddedRanges returns the ranges of lines of the new file the diff adds
+func (f *File) AddedRanges() []Range {
+	return f.ranges(AddedLine, func(l Line) int { return l.NewNumber })
+}
+
+// DeletedRanges returns the ranges of lines of the old file the diff deletes
+func (f *File) DeletedRanges() []Range {
+	return f.ranges(DeletedLine, func(l Line) int { return l.OldNumber })
+}
+
+func (f *File) ranges(kind LineKind, number func(Line) int) []Range {
+	ranges := make([]Range, 0)
+	for _, hunk := range f.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind != kind {
+				continue
+			}
+
+			n := number(line)
+			if last := len(ranges) - 1; last >= 0 && ranges[last].End == n-1 {
+				ranges[last].End = n
+				continue
+			}
+			ranges = append(ranges, Range{Start: n, End: n})
+		}
+	}
+
+	return ranges
+}
+
+// generatedMarkers mark generated files when found in their added lines, see
+// https://golang.org/s/generatedcode
+var generatedMarkers = []string{"Code generated", "DO NOT EDIT", "@generated"}
+
+// generatedPatterns match the base names of files which are usually generated
+var generatedPatterns = []string{
+	"*.pb.go", "*_generated.go", "*.gen.go", "zz_generated*.go",
+	"*.min.js", "*.min.css", "*.map",
+	"package-lock.json", "yarn.lock", "Gopkg.lock", "glide.lock", "go.sum",
+}
+
+// generatedDirs are directories which hold generated or vendored files
+var generatedDirs = []string{"vendor", "node_modules"}
+
+// IsGenerated reports whether the file is likely generated or vendored rather
+// than written by hand, going by its name and any generated code marker it
+// adds
+func (f *File) IsGenerated() bool {
+	name := f.Name()
+
+	for _, dir := range strings.Split(path.Dir(name), "/") {
+		for _, generated := range generatedDirs {
+			if dir == generated {
+				return true
+			}
+		}
+	}
+
+	for _, pattern := range generatedPatterns {
+		if ok, _ := path.Match(pattern, path.Base(name)); ok {
+			return true
+		}
+	}
+
+	for _, hunk := range f.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind != AddedLine {
+				continue
+			}
+			for _, marker := range generatedMarkers {
+				if strings.Contains(line.Content, marker) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// Names returns the name of each file, see File.Name
+func Names(files []*File) []string {
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+
+	return names
+}
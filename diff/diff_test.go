@@ -0,0 +1,121 @@
+package diff
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseFile(t *testing.T, path string) []*File {
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	files, err := Parse(f)
+	assert.NoError(t, err)
+
+	return files
+}
+
+func TestParse(t *testing.T) {
+	files := parseFile(t, "testdata/pull_request.diff")
+	assert.Equal(t, []string{"api/api.go", "api/zz_generated.deepcopy.go", "docs/old.md", "docs/README.md", "logo.png"}, Names(files))
+
+	modified := files[0]
+	assert.Equal(t, Modified, modified.Status)
+	assert.Equal(t, "api/api.go", modified.OldName)
+	assert.Equal(t, 2, len(modified.Hunks))
+
+	hunk := modified.Hunks[0]
+	assert.Equal(t, Hunk{OldStart: 10, OldLines: 7, NewStart: 10, NewLines: 8, Section: "import ("}, Hunk{
+		OldStart: hunk.OldStart, OldLines: hunk.OldLines, NewStart: hunk.NewStart, NewLines: hunk.NewLines, Section: hunk.Section,
+	})
+	assert.Equal(t, 9, len(hunk.Lines))
+	assert.Equal(t, Line{Kind: DeletedLine, Content: "\t\"github.com/sirupsen/logrus\"", OldNumber: 13}, hunk.Lines[3])
+	assert.Equal(t, Line{Kind: AddedLine, Content: "\t\"github.com/doodles526/gogitpr/telemetry\"", NewNumber: 13}, hunk.Lines[4])
+	assert.Equal(t, Line{Kind: Context, Content: ")", OldNumber: 14, NewNumber: 15}, hunk.Lines[6])
+	assert.Equal(t, Line{Kind: Context, Content: "", OldNumber: 15, NewNumber: 16}, hunk.Lines[7], "Blank context lines should keep their numbers")
+
+	assert.Equal(t, []Range{{Start: 13, End: 14}}, modified.AddedRanges())
+	assert.Equal(t, []Range{{Start: 13, End: 13}, {Start: 41, End: 41}}, modified.DeletedRanges())
+	assert.False(t, modified.IsGenerated())
+
+	added := files[1]
+	assert.Equal(t, Added, added.Status)
+	assert.Equal(t, "", added.OldName)
+	assert.Equal(t, []Range{{Start: 1, End: 3}}, added.AddedRanges())
+	assert.True(t, added.IsGenerated())
+
+	deleted := files[2]
+	assert.Equal(t, Deleted, deleted.Status)
+	assert.Equal(t, "", deleted.NewName)
+	assert.Equal(t, "docs/old.md", deleted.Name())
+	assert.True(t, deleted.Hunks[0].Lines[1].NoNewline)
+	assert.False(t, deleted.Hunks[0].Lines[0].NoNewline)
+
+	renamed := files[3]
+	assert.Equal(t, Renamed, renamed.Status)
+	assert.Equal(t, "README.md", renamed.OldName)
+	assert.Equal(t, "docs/README.md", renamed.NewName)
+	assert.Equal(t, 2, len(renamed.Hunks[0].Lines))
+
+	binary := files[4]
+	assert.True(t, binary.Binary)
+	assert.Equal(t, 0, len(binary.Hunks))
+}
+
+func TestParsePatch(t *testing.T) {
+	files := parseFile(t, "testdata/pull_request.patch")
+	assert.Equal(t, []string{"hello.go", "hello.go"}, Names(files), "Each commit's change should be returned")
+
+	assert.Equal(t, []Range{{Start: 3, End: 3}}, files[0].AddedRanges())
+	assert.Equal(t, 3, len(files[1].Hunks[0].Lines), "The signature after a hunk shouldn't be part of it")
+	assert.Equal(t, []Range{{Start: 4, End: 4}}, files[1].AddedRanges())
+}
+
+func TestParsePlain(t *testing.T) {
+	files, err := Parse(strings.NewReader(`--- hello.go	2011-01-25 19:01:12.000000000 +0000
++++ hello.go	2011-01-26 19:01:12.000000000 +0000
+@@ -1 +1,2 @@
+ package main
++func hello() {}
+--- "with space.txt"
++++ "with space.txt"
+@@ -1 +1 @@
+-a
++b
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello.go", "with space.txt"}, Names(files))
+	assert.Equal(t, Modified, files[0].Status)
+	assert.Equal(t, 2, len(files[0].Hunks[0].Lines))
+}
+
+func TestParseMalformed(t *testing.T) {
+	for name, text := range map[string]string{
+		"bad header": "--- a/x\n+++ b/x\n@@ -1,a +1 @@\n",
+		"truncated":  "--- a/x\n+++ b/x\n@@ -1,3 +1,3 @@\n a\n",
+		"too long":   "--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n-b\n",
+		"bad line":   "--- a/x\n+++ b/x\n@@ -1 +1 @@\n*a\n",
+	} {
+		_, err := Parse(strings.NewReader(text))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	for name, generated := range map[string]bool{
+		"api/pr.pb.go":             true,
+		"vendor/github.com/x/y.go": true,
+		"web/node_modules/a/b.js":  true,
+		"web/app.min.js":           true,
+		"glide.lock":               true,
+		"api/pr.go":                false,
+		"docs/vendoring.md":        false,
+	} {
+		f := &File{NewName: name, Status: Modified}
+		assert.Equal(t, generated, f.IsGenerated(), name)
+	}
+}
@@ -0,0 +1,260 @@
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// maxLineLength is the longest line Parse reads
+const maxLineLength = 16 * 1024 * 1024
+
+// Parse reads a unified diff, either plain or as generated by git, returning
+// the files it changes in order. Text around the diff, such as the commit
+// messages of a patch, is skipped. A file changed by several commits of a
+// patch is returned once per commit
+func Parse(r io.Reader) ([]*File, error) {
+	p := &parser{files: make([]*File, 0)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+	for scanner.Scan() {
+		p.lineNumber++
+		if err := p.parseLine(scanner.Text()); err != nil {
+			return nil, fmt.Errorf("line %d: %v", p.lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if p.hunk != nil && (p.oldLeft > 0 || p.newLeft > 0) {
+		return nil, fmt.Errorf("line %d: hunk of %s ends early", p.lineNumber, p.file.Name())
+	}
+
+	return p.files, nil
+}
+
+type parser struct {
+	files      []*File
+	lineNumber int
+
+	// file is the file being parsed, and git whether it began with a git
+	// header
+	file *File
+	git  bool
+
+	// hunk is the hunk being parsed, with how many of its old and new lines
+	// are left
+	hunk             *Hunk
+	oldLeft          int
+	newLeft          int
+	oldLine, newLine int
+}
+
+func (p *parser) parseLine(line string) error {
+	if p.hunk != nil && (p.oldLeft > 0 || p.newLeft > 0) {
+		return p.parseHunkLine(line)
+	}
+
+	if p.hunk != nil && strings.HasPrefix(line, `\`) {
+		// no newline at the end of the hunk's last line
+		p.markNoNewline()
+		return nil
+	}
+	p.hunk = nil
+
+	switch {
+	case strings.HasPrefix(line, "diff --git "):
+		p.startFile(true)
+		p.file.OldName, p.file.NewName = parseGitNames(strings.TrimPrefix(line, "diff --git "))
+
+	case strings.HasPrefix(line, "--- "):
+		// a plain diff has no header before its names
+		if p.file == nil || !p.git || len(p.file.Hunks) != 0 {
+			p.startFile(false)
+		}
+		name := parseName(strings.TrimPrefix(line, "--- "))
+		if name == "" {
+			p.file.Status = Added
+		}
+		p.file.OldName = name
+
+	case strings.HasPrefix(line, "+++ ") && p.file != nil:
+		name := parseName(strings.TrimPrefix(line, "+++ "))
+		if name == "" {
+			p.file.Status = Deleted
+		}
+		p.file.NewName = name
+
+	case strings.HasPrefix(line, "@@ ") && p.file != nil:
+		return p.startHunk(line)
+
+	case p.file == nil:
+		// text before the first file
+
+	case strings.HasPrefix(line, "new file mode"):
+		p.file.Status = Added
+		p.file.OldName = ""
+
+	case strings.HasPrefix(line, "deleted file mode"):
+		p.file.Status = Deleted
+		p.file.NewName = ""
+
+	case strings.HasPrefix(line, "rename from "):
+		p.file.Status = Renamed
+		p.file.OldName = unquote(strings.TrimPrefix(line, "rename from "))
+
+	case strings.HasPrefix(line, "rename to "):
+		p.file.Status = Renamed
+		p.file.NewName = unquote(strings.TrimPrefix(line, "rename to "))
+
+	case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+		p.file.Binary = true
+	}
+
+	return nil
+}
+
+func (p *parser) startFile(git bool) {
+	p.file = &File{Status: Modified, Hunks: make([]Hunk, 0)}
+	p.git = git
+	p.files = append(p.files, p.file)
+}
+
+func (p *parser) startHunk(line string) error {
+	match := hunkHeader.FindStringSubmatch(line)
+	if match == nil {
+		return fmt.Errorf("malformed hunk header %q", line)
+	}
+
+	count := func(s string) int {
+		if len(s) == 0 {
+			// a count is omitted when it's one
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	oldStart, _ := strconv.Atoi(match[1])
+	newStart, _ := strconv.Atoi(match[3])
+
+	p.file.Hunks = append(p.file.Hunks, Hunk{
+		OldStart: oldStart,
+		OldLines: count(match[2]),
+		NewStart: newStart,
+		NewLines: count(match[4]),
+		Section:  match[5],
+		Lines:    make([]Line, 0),
+	})
+	p.hunk = &p.file.Hunks[len(p.file.Hunks)-1]
+	p.oldLeft, p.newLeft = p.hunk.OldLines, p.hunk.NewLines
+	p.oldLine, p.newLine = p.hunk.OldStart, p.hunk.NewStart
+
+	return nil
+}
+
+func (p *parser) parseHunkLine(line string) error {
+	if strings.HasPrefix(line, `\`) {
+		p.markNoNewline()
+		return nil
+	}
+
+	kind, content := Context, line
+	if len(line) != 0 {
+		content = line[1:]
+		switch line[0] {
+		case ' ':
+		case '+':
+			kind = AddedLine
+		case '-':
+			kind = DeletedLine
+		default:
+			return fmt.Errorf("unexpected line in hunk of %s: %q", p.file.Name(), line)
+		}
+	}
+	// an empty line is context whose leading space was stripped
+
+	l := Line{Kind: kind, Content: content}
+	switch kind {
+	case Context:
+		l.OldNumber, l.NewNumber = p.oldLine, p.newLine
+		p.oldLine++
+		p.newLine++
+		p.oldLeft--
+		p.newLeft--
+	case AddedLine:
+		l.NewNumber = p.newLine
+		p.newLine++
+		p.newLeft--
+	case DeletedLine:
+		l.OldNumber = p.oldLine
+		p.oldLine++
+		p.oldLeft--
+	}
+
+	if p.oldLeft < 0 || p.newLeft < 0 {
+		return fmt.Errorf("hunk of %s is longer than its header", p.file.Name())
+	}
+
+	p.hunk.Lines = append(p.hunk.Lines, l)
+	return nil
+}
+
+func (p *parser) markNoNewline() {
+	if n := len(p.hunk.Lines); n != 0 {
+		p.hunk.Lines[n-1].NoNewline = true
+	}
+}
+
+// parseGitNames splits the "a/old b/new" of a git diff header. Names with
+// spaces are ambiguous here, but are given again by the --- and +++ lines
+func parseGitNames(names string) (string, string) {
+	if strings.HasPrefix(names, `"`) {
+		if end := strings.Index(names[1:], `" `); end >= 0 {
+			return parseName(names[:end+2]), parseName(strings.TrimSpace(names[end+2:]))
+		}
+	}
+
+	if idx := strings.Index(names, " b/"); idx >= 0 {
+		return parseName(names[:idx]), parseName(names[idx+1:])
+	}
+
+	return names, names
+}
+
+// parseName returns the file name of a --- or +++ line, without its a/ or b/
+// prefix, or blank for /dev/null
+func parseName(name string) string {
+	// plain diffs may follow the name with a timestamp
+	if idx := strings.Index(name, "\t"); idx >= 0 {
+		name = name[:idx]
+	}
+	name = unquote(name)
+
+	if name == "/dev/null" {
+		return ""
+	}
+
+	if strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/") {
+		return name[2:]
+	}
+
+	return name
+}
+
+// unquote unquotes names git quotes for containing special characters
+func unquote(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			return unquoted
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/doodles526/gogitpr/db"
+	"github.com/pkg/errors"
+)
+
+// runExport syncs pull requests, then exports the DB to path, or stdout if
+// path is "-"
+func runExport(s *syncer, path string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("export requires a path")
+	}
+
+	if err := s.syncAll(); err != nil {
+		return err
+	}
+
+	export := db.Export
+	if isArchive(path) {
+		export = db.ExportArchive
+	}
+
+	if path == "-" {
+		return errors.Wrap(export(s.db, os.Stdout), "exporting DB")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "creating export")
+	}
+
+	if err := export(s.db, f); err != nil {
+		f.Close()
+		return errors.Wrap(err, "exporting DB")
+	}
+
+	return f.Close()
+}
+
+// seedDB imports the export at path into prDB
+func seedDB(prDB db.DB, path string) (*db.Header, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if isArchive(path) {
+		return db.ImportArchive(prDB, f)
+	}
+
+	return db.Import(prDB, f)
+}
+
+func isArchive(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
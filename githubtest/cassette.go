@@ -0,0 +1,257 @@
+package githubtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// RecordEnv is the envvar which, when set, has tests record cassettes from
+// the real github API rather than replay them. See Recording
+const RecordEnv = "GITHUBTEST_RECORD"
+
+// Recording reports whether RecordEnv is set
+func Recording() bool {
+	return len(os.Getenv(RecordEnv)) != 0
+}
+
+// Interaction is a request made to the github API and the response to it
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the part of a request interactions are matched on
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is enough of a response to replay it
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Cassette holds recorded interactions. Credentials are never recorded
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+
+	mu sync.Mutex
+	// played counts how many interactions of each request have been replayed
+	played map[string]int
+}
+
+// LoadCassette reads the cassette saved at path
+func LoadCassette(path string) (*Cassette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c := &Cassette{}
+	if err := json.NewDecoder(f).Decode(c); err != nil {
+		return nil, fmt.Errorf("reading cassette %s: %v", path, err)
+	}
+
+	return c, nil
+}
+
+// Save writes the cassette to path
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	buf, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(buf, '\n'), 0644)
+}
+
+// redactedParams are query parameters never recorded
+var redactedParams = []string{"access_token", "client_id", "client_secret"}
+
+// redactedHeaders are response headers never recorded
+var redactedHeaders = []string{"Set-Cookie"}
+
+// requestKey identifies a request by method, path and query, ignoring the
+// scheme and host so cassettes replay against any base URL
+func requestKey(method string, u *url.URL, body string) string {
+	query := u.Query()
+	for _, param := range redactedParams {
+		query.Del(param)
+	}
+
+	return fmt.Sprintf("%s %s?%s %s", method, u.Path, query.Encode(), body)
+}
+
+// Record returns an http.RoundTripper which makes requests through next,
+// recording each in the cassette. It can be used as an api.Middleware
+func (c *Cassette) Record(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := readBody(req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		respBody, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+		u := *req.URL
+		query := u.Query()
+		for _, param := range redactedParams {
+			query.Del(param)
+		}
+		u.RawQuery = query.Encode()
+
+		header := make(http.Header)
+		for name, values := range resp.Header {
+			header[name] = values
+		}
+		for _, name := range redactedHeaders {
+			header.Del(name)
+		}
+
+		c.mu.Lock()
+		c.Interactions = append(c.Interactions, Interaction{
+			Request: RecordedRequest{
+				Method: req.Method,
+				URL:    u.String(),
+				Body:   body,
+			},
+			Response: RecordedResponse{
+				StatusCode: resp.StatusCode,
+				Header:     header,
+				Body:       string(respBody),
+			},
+		})
+		c.mu.Unlock()
+
+		return resp, nil
+	})
+}
+
+// Replay returns an http.RoundTripper answering requests from the cassette.
+// Requests are matched on their method, path, query and body. Repeated
+// requests are answered with each of their interactions in the order
+// recorded, the last being repeated. A request with no interaction fails
+func (c *Cassette) Replay() http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := readBody(req)
+		if err != nil {
+			return nil, err
+		}
+		key := requestKey(req.Method, req.URL, body)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if c.played == nil {
+			c.played = make(map[string]int)
+		}
+
+		var match *Interaction
+		seen := 0
+		for i := range c.Interactions {
+			interaction := &c.Interactions[i]
+			u, err := url.Parse(interaction.Request.URL)
+			if err != nil {
+				return nil, err
+			}
+			if requestKey(interaction.Request.Method, u, interaction.Request.Body) != key {
+				continue
+			}
+
+			match = interaction
+			if seen == c.played[key] {
+				break
+			}
+			seen++
+		}
+
+		if match == nil {
+			return nil, fmt.Errorf("githubtest: no interaction recorded for %s %s", req.Method, req.URL)
+		}
+		c.played[key]++
+
+		header := make(http.Header)
+		for name, values := range match.Response.Header {
+			header[name] = values
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", match.Response.StatusCode, http.StatusText(match.Response.StatusCode)),
+			StatusCode:    match.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(match.Response.Body))),
+			ContentLength: int64(len(match.Response.Body)),
+			Request:       req,
+		}, nil
+	})
+}
+
+// NewTransport replays the cassette at path, or when Recording records to it
+// from the real github API via next, or http.DefaultTransport when nil. The
+// returned func saves the cassette when recording, and must be called once
+// the test is done
+func NewTransport(path string, next http.RoundTripper) (http.RoundTripper, func() error, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if Recording() {
+		c := &Cassette{}
+		return c.Record(next), func() error { return c.Save(path) }, nil
+	}
+
+	c, err := LoadCassette(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c.Replay(), func() error { return nil }, nil
+}
+
+// readBody reads the body of req, leaving it to be read again
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil {
+		return "", nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return string(body), nil
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,80 @@
+package githubtest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func get(t *testing.T, client *http.Client, u string) (int, string, error) {
+	resp, err := client.Get(u)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	return resp.StatusCode, string(body), nil
+}
+
+func TestCassetteRecordReplay(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	calls := 0
+	s.Handle("GET", "/users/octocat", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		w.Write([]byte(strings.Repeat("a", calls)))
+	}))
+
+	dir, err := ioutil.TempDir("", "githubtest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	recorded := &Cassette{}
+	client := &http.Client{Transport: recorded.Record(http.DefaultTransport)}
+	for _, want := range []string{"a", "aa"} {
+		_, body, err := get(t, client, s.URL+"/users/octocat?access_token=s3cr3t")
+		assert.NoError(t, err)
+		assert.Equal(t, want, body, "Recorded responses should be passed through")
+	}
+	_, _, err = get(t, client, s.URL+"/users/hubot")
+	assert.NoError(t, err)
+	assert.NoError(t, recorded.Save(path))
+
+	saved, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(saved), "s3cr3t", "Tokens shouldn't be recorded")
+	assert.NotContains(t, string(saved), "secret", "Cookies shouldn't be recorded")
+
+	c, err := LoadCassette(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(c.Interactions))
+
+	// replayed against another host, as cassettes are recorded from github
+	client = &http.Client{Transport: c.Replay()}
+	for _, want := range []string{"a", "aa", "aa"} {
+		status, body, err := get(t, client, "https://api.github.com/users/octocat")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, want, body, "Repeated requests should be replayed in order, then the last repeated")
+	}
+
+	status, _, err := get(t, client, "https://api.github.com/users/hubot")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	_, _, err = get(t, client, "https://api.github.com/users/octocat?page=2")
+	assert.Error(t, err, "Requests not recorded should fail")
+
+	assert.Equal(t, 3, s.Requests("GET", "/users/octocat")+s.Requests("GET", "/users/hubot"))
+}
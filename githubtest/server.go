@@ -0,0 +1,182 @@
+// Package githubtest provides a fake github API server and record and replay
+// of real github API interactions, so code using the github API can be tested
+// offline
+package githubtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultPerPage is the page size lists are served in unless a request
+	// sets per_page, as github does
+	DefaultPerPage = 30
+	// MaxPerPage is the largest page size github serves
+	MaxPerPage = 100
+)
+
+// Server is a fake github API. Responses are registered by method and path,
+// any other request is answered with a 404 as github would
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	routes   map[string]http.Handler
+	requests map[string]int
+}
+
+// NewServer starts a Server with no responses registered. The caller must
+// Close it
+func NewServer() *Server {
+	s := &Server{
+		routes:   make(map[string]http.Handler),
+		requests: make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// Handle has handler answer requests of method to path
+func (s *Server) Handle(method, path string, handler http.Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.routes[routeKey(method, path)] = handler
+}
+
+// HandleObject answers requests of method to path with status and the JSON
+// body
+func (s *Server) HandleObject(method, path string, status int, body string) {
+	s.Handle(method, path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+}
+
+// HandleList answers GET requests to path with a JSON array of items, each
+// itself JSON, paginated with Link headers as github does
+func (s *Server) HandleList(path string, items ...string) {
+	s.Handle("GET", path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, perPage, err := pageArgs(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprintf(w, `{"message": %q}`, err.Error())
+			return
+		}
+
+		lastPage := (len(items) + perPage - 1) / perPage
+		if lastPage == 0 {
+			lastPage = 1
+		}
+
+		start, end := (page-1)*perPage, page*perPage
+		if start > len(items) {
+			start = len(items)
+		}
+		if end > len(items) {
+			end = len(items)
+		}
+
+		if links := pageLinks(r, page, lastPage); len(links) != 0 {
+			w.Header().Set("Link", links)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprintf(w, "[%s]", strings.Join(items[start:end], ","))
+	}))
+}
+
+// Requests returns how many requests of method have been made to path
+func (s *Server) Requests(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[routeKey(method, path)]
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	key := routeKey(r.Method, r.URL.Path)
+
+	s.mu.Lock()
+	s.requests[key]++
+	handler, ok := s.routes[key]
+	s.mu.Unlock()
+
+	if !ok {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found", "documentation_url": "https://developer.github.com/v3"}`)
+		return
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// pageArgs reads the page and per_page query parameters, defaulting them as
+// github does
+func pageArgs(query url.Values) (int, int, error) {
+	page, perPage := 1, DefaultPerPage
+
+	if p := query.Get("page"); len(p) != 0 {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid page %q", p)
+		}
+		if n > 1 {
+			page = n
+		}
+	}
+
+	if p := query.Get("per_page"); len(p) != 0 {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid per_page %q", p)
+		}
+		if n > 0 {
+			perPage = n
+		}
+		if perPage > MaxPerPage {
+			perPage = MaxPerPage
+		}
+	}
+
+	return page, perPage, nil
+}
+
+// pageLinks builds the Link header of page, pointing at the pages around it
+// with the same query as r. github omits the header when there's one page
+func pageLinks(r *http.Request, page, lastPage int) string {
+	if lastPage == 1 {
+		return ""
+	}
+
+	link := func(n int, rel string) string {
+		u := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(n))
+		u.RawQuery = query.Encode()
+
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+	}
+
+	links := make([]string, 0, 4)
+	if page < lastPage {
+		links = append(links, link(page+1, "next"), link(lastPage, "last"))
+	}
+	if page > 1 {
+		links = append(links, link(1, "first"), link(page-1, "prev"))
+	}
+
+	return strings.Join(links, ", ")
+}
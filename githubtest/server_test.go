@@ -0,0 +1,86 @@
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/peterhellberg/link"
+	"github.com/stretchr/testify/assert"
+)
+
+func getList(t *testing.T, u string) ([]int, link.Group) {
+	resp, err := http.Get(u)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	items := make([]struct {
+		ID int `json:"id"`
+	}, 0)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&items))
+
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+
+	return ids, link.ParseHeader(resp.Header)
+}
+
+func TestServerHandleList(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	items := make([]string, 0, 5)
+	for i := 1; i <= 5; i++ {
+		items = append(items, fmt.Sprintf(`{"id": %d}`, i))
+	}
+	s.HandleList("/repos/octocat/Hello-World/pulls", items...)
+	base := s.URL + "/repos/octocat/Hello-World/pulls"
+
+	ids, links := getList(t, base)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+	assert.Nil(t, links, "A single page shouldn't have links")
+
+	ids, links = getList(t, base+"?per_page=2&state=all")
+	assert.Equal(t, []int{1, 2}, ids)
+	assert.Equal(t, base+"?page=2&per_page=2&state=all", links["next"].URI)
+	assert.Equal(t, base+"?page=3&per_page=2&state=all", links["last"].URI)
+	assert.Nil(t, links["prev"])
+
+	ids, links = getList(t, links["last"].URI)
+	assert.Equal(t, []int{5}, ids)
+	assert.Equal(t, base+"?page=2&per_page=2&state=all", links["prev"].URI)
+	assert.Equal(t, base+"?page=1&per_page=2&state=all", links["first"].URI)
+	assert.Nil(t, links["next"])
+
+	ids, _ = getList(t, base+"?per_page=2&page=9")
+	assert.Equal(t, []int{}, ids, "Pages past the last should be empty")
+
+	assert.Equal(t, 4, s.Requests("GET", "/repos/octocat/Hello-World/pulls"))
+}
+
+func TestServerHandleObject(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	s.HandleObject("GET", "/users/octocat", http.StatusOK, `{"login": "octocat"}`)
+
+	resp, err := http.Get(s.URL + "/users/octocat")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Post(s.URL+"/users/octocat", "application/json", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Unregistered methods should be not found")
+
+	resp, err = http.Get(s.URL + "/users/hubot")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, 1, s.Requests("GET", "/users/hubot"))
+}
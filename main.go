@@ -4,11 +4,23 @@ import (
 	"github.com/doodles526/gogitpr/api"
 	"github.com/doodles526/gogitpr/config"
 	"github.com/doodles526/gogitpr/db"
+	"github.com/doodles526/gogitpr/telemetry"
 
 	"fmt"
 	"os"
 )
 
+const usage = `Usage: gogitpr [command]
+
+Commands:
+  sync    fetch pull requests once and print the configured reports (default)
+  serve   fetch pull requests, then serve them over an HTTP JSON API
+  daemon  serve pull requests while syncing them periodically
+  stats   fetch pull requests with their reviews and print metrics
+  stale   fetch pull requests and report the stale ones
+  export  fetch pull requests and export the DB to a path, or - for stdout
+`
+
 func main() {
 	cfg, err := config.NewConfig()
 	if err != nil {
@@ -16,11 +28,32 @@ func main() {
 		os.Exit(1)
 	}
 
+	reg := telemetry.NewRegistry()
+
+	pagination := api.PaginationPageNumbers
+	if cfg.FollowNextLinks {
+		pagination = api.PaginationFollowNext
+	}
+
 	apiArgs := &api.GithubAPIArgs{
 		BaseURL:         cfg.BaseURL,
 		Token:           cfg.GithubToken,
 		ApplicationName: cfg.ApplicationName,
+		APIVersion:      cfg.APIVersion,
 		Logger:          cfg.Logger,
+		Registry:        reg,
+		Retry: &api.RetryPolicy{
+			MaxAttempts:          cfg.RetryMaxAttempts,
+			BaseDelay:            cfg.RetryBaseDelay,
+			MaxDelay:             cfg.RetryMaxDelay,
+			RetryableStatusCodes: api.DefaultRetryPolicy.RetryableStatusCodes,
+		},
+		Middleware: []api.Middleware{
+			api.DebugLogging(cfg.Logger),
+			api.Timeout(cfg.RequestTimeout),
+		},
+		Pagination: pagination,
+		PerPage:    cfg.PerPage,
 		// use default version
 	}
 
@@ -30,17 +63,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	prArgs := &api.PullRequestArgs{
-		User: cfg.GithubUser,
-		Org:  cfg.GithubOrg,
-	}
-
-	prs, err := gh.PullRequest().Get(prArgs)
-	if err != nil {
-		fmt.Printf("Error getting Pull Requests: %+v", err)
-		os.Exit(1)
-	}
-
 	dbArgs := &db.Args{
 		Logger: cfg.Logger,
 	}
@@ -50,19 +72,48 @@ func main() {
 		fmt.Printf("Error Creating DB: %+v", err)
 		os.Exit(1)
 	}
+	db.RegisterMetrics(reg, prDB)
 
-	if err := prDB.StorePullRequestBatch(prs); err != nil {
-		fmt.Printf("Error storing PRs: %+v", err)
-		os.Exit(1)
+	if len(cfg.SeedFile) != 0 {
+		header, err := seedDB(prDB, cfg.SeedFile)
+		if err != nil {
+			fmt.Printf("Error seeding DB: %+v", err)
+			os.Exit(1)
+		}
+		cfg.Logger.Infof("seeded DB from %s, exported %s", cfg.SeedFile, header.ExportedAt)
 	}
 
-	allPRs, err := prDB.GetAllPullRequests()
-	if err != nil {
-		fmt.Printf("Error Fetching PRs: %+v", err)
-		os.Exit(1)
+	s := newSyncer(cfg, gh, prDB, reg)
+
+	command := "sync"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
 	}
 
-	if cfg.PrintResult {
-		fmt.Println(allPRs)
+	switch command {
+	case "sync":
+		err = runSync(s)
+	case "serve":
+		err = runServe(s, reg)
+	case "daemon":
+		err = runDaemon(s, reg)
+	case "stats":
+		err = runStats(s)
+	case "stale":
+		err = runStale(s)
+	case "export":
+		path := ""
+		if len(os.Args) > 2 {
+			path = os.Args[2]
+		}
+		err = runExport(s, path)
+	default:
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Printf("Error running %s: %+v", command, err)
+		os.Exit(1)
 	}
 }
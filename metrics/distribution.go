@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+)
+
+// DurationStats summarises a distribution of durations. Every field is zero
+// when Count is zero
+type DurationStats struct {
+	Count int
+	Mean  time.Duration
+	P50   time.Duration
+	P75   time.Duration
+	P90   time.Duration
+	P95   time.Duration
+	Max   time.Duration
+}
+
+func newDurationStats(durations []time.Duration) DurationStats {
+	if len(durations) == 0 {
+		return DurationStats{}
+	}
+
+	sort.Slice(durations, func(a, b int) bool {
+		return durations[a] < durations[b]
+	})
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+
+	return DurationStats{
+		Count: len(durations),
+		Mean:  total / time.Duration(len(durations)),
+		P50:   durations[rank(50, len(durations))],
+		P75:   durations[rank(75, len(durations))],
+		P90:   durations[rank(90, len(durations))],
+		P95:   durations[rank(95, len(durations))],
+		Max:   durations[len(durations)-1],
+	}
+}
+
+// SizeBucket counts the pull requests which changed at most MaxLines lines,
+// and more than the bucket before. The last bucket has no MaxLines
+type SizeBucket struct {
+	Label    string
+	MaxLines int
+	Count    int
+}
+
+// SizeBuckets are the buckets SizeStats.Buckets are counted in
+var SizeBuckets = []SizeBucket{
+	{Label: "XS", MaxLines: 10},
+	{Label: "S", MaxLines: 100},
+	{Label: "M", MaxLines: 500},
+	{Label: "L", MaxLines: 1000},
+	{Label: "XL"},
+}
+
+// SizeStats summarises the distribution of lines changed by pull requests
+type SizeStats struct {
+	Count   int
+	Mean    int
+	P50     int
+	P75     int
+	P90     int
+	P95     int
+	Max     int
+	Buckets []SizeBucket
+}
+
+func newSizeStats(sizes []int) SizeStats {
+	stats := SizeStats{
+		Count:   len(sizes),
+		Buckets: make([]SizeBucket, len(SizeBuckets)),
+	}
+	copy(stats.Buckets, SizeBuckets)
+
+	if len(sizes) == 0 {
+		return stats
+	}
+
+	sort.Ints(sizes)
+
+	total := 0
+	for _, size := range sizes {
+		total += size
+
+		for idx := range stats.Buckets {
+			b := &stats.Buckets[idx]
+			if size <= b.MaxLines || idx == len(stats.Buckets)-1 {
+				b.Count++
+				break
+			}
+		}
+	}
+
+	stats.Mean = total / len(sizes)
+	stats.P50 = sizes[rank(50, len(sizes))]
+	stats.P75 = sizes[rank(75, len(sizes))]
+	stats.P90 = sizes[rank(90, len(sizes))]
+	stats.P95 = sizes[rank(95, len(sizes))]
+	stats.Max = sizes[len(sizes)-1]
+
+	return stats
+}
+
+// rank returns the index of the pth percentile of n sorted values, using the
+// nearest rank method
+func rank(p, n int) int {
+	r := (p*n + 99) / 100
+	if r < 1 {
+		r = 1
+	}
+
+	return r - 1
+}
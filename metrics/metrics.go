@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+)
+
+// Report holds the Stats of every pull request, and of them grouped by base
+// repo, author and the week they were opened
+type Report struct {
+	Overall  Stats
+	ByRepo   []Stats
+	ByAuthor []Stats
+	ByWeek   []Stats
+}
+
+// Stats summarises a group of pull requests
+type Stats struct {
+	// Key is the repo, author or week of the group, blank for Overall
+	Key string
+
+	Opened int
+	Merged int
+	// Abandoned are closed without being merged
+	Abandoned int
+
+	// LeadTime runs from creation to merge
+	LeadTime DurationStats
+	// TimeToFirstReview runs from creation to the first review submitted by
+	// someone other than the author
+	TimeToFirstReview DurationStats
+	// TimeToMergeAfterApproval runs from the first approval to merge
+	TimeToMergeAfterApproval DurationStats
+	// Size only covers pull requests whose size is known. See
+	// api.PullRequestData.HasSize
+	Size SizeStats
+}
+
+// MergeRate returns the fraction of closed pull requests which were merged,
+// or 0 if none were closed
+func (s Stats) MergeRate() float64 {
+	closed := s.Merged + s.Abandoned
+	if closed == 0 {
+		return 0
+	}
+
+	return float64(s.Merged) / float64(closed)
+}
+
+// sample is what a single pull request contributes to Stats
+type sample struct {
+	pr api.PullRequestData
+
+	leadTime          *time.Duration
+	firstReview       *time.Duration
+	mergeAfterApprove *time.Duration
+}
+
+// Compute builds a report of every pull request stored in d matching f, using
+// whichever reviews are stored for them
+func Compute(d db.DB, f db.PRFilterFunc) (*Report, error) {
+	prs, err := d.GetFilterPullRequests(f)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]sample, 0, len(prs))
+	for _, pr := range prs {
+		reviews, err := d.GetReviews(pr.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		samples = append(samples, newSample(pr, reviews))
+	}
+
+	report := &Report{
+		Overall: newStats("", samples),
+		ByRepo: groupStats(samples, func(pr api.PullRequestData) string {
+			if !pr.Base.HasRepo() {
+				return "(unknown)"
+			}
+			return pr.Base.Repo.FullName
+		}),
+		ByAuthor: groupStats(samples, func(pr api.PullRequestData) string {
+			return pr.User.Login
+		}),
+		ByWeek: groupStats(samples, func(pr api.PullRequestData) string {
+			return Week(pr.CreatedAt)
+		}),
+	}
+
+	return report, nil
+}
+
+// Week returns the ISO week t falls in, e.g. "2011-W04"
+func Week(t time.Time) string {
+	year, week := t.ISOWeek()
+
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
+
+func newSample(pr api.PullRequestData, reviews []api.ReviewData) sample {
+	s := sample{pr: pr}
+
+	if pr.IsMerged() {
+		s.leadTime = since(pr.CreatedAt, *pr.MergedAt)
+	}
+
+	// reviews are stored ordered by submission, so the first found is the
+	// earliest
+	for _, review := range reviews {
+		if review.SubmittedAt == nil || review.User.Login == pr.User.Login {
+			continue
+		}
+
+		if s.firstReview == nil {
+			s.firstReview = since(pr.CreatedAt, *review.SubmittedAt)
+		}
+
+		if review.IsApproval() && pr.IsMerged() && !review.SubmittedAt.After(*pr.MergedAt) {
+			s.mergeAfterApprove = since(*review.SubmittedAt, *pr.MergedAt)
+			break
+		}
+	}
+
+	return s
+}
+
+// since returns the duration from start to end, or nil if end is before start
+// as happens with clock skew
+func since(start, end time.Time) *time.Duration {
+	if end.Before(start) {
+		return nil
+	}
+	d := end.Sub(start)
+
+	return &d
+}
+
+func groupStats(samples []sample, key func(pr api.PullRequestData) string) []Stats {
+	groups := make(map[string][]sample)
+	for _, s := range samples {
+		k := key(s.pr)
+		groups[k] = append(groups[k], s)
+	}
+
+	stats := make([]Stats, 0, len(groups))
+	for k, group := range groups {
+		stats = append(stats, newStats(k, group))
+	}
+	sort.Slice(stats, func(a, b int) bool {
+		return stats[a].Key < stats[b].Key
+	})
+
+	return stats
+}
+
+func newStats(key string, samples []sample) Stats {
+	stats := Stats{Key: key, Opened: len(samples)}
+
+	leadTimes := make([]time.Duration, 0)
+	firstReviews := make([]time.Duration, 0)
+	mergeAfterApproves := make([]time.Duration, 0)
+	sizes := make([]int, 0)
+
+	for _, s := range samples {
+		switch {
+		case s.pr.IsMerged():
+			stats.Merged++
+		case s.pr.IsClosed():
+			stats.Abandoned++
+		}
+
+		if s.leadTime != nil {
+			leadTimes = append(leadTimes, *s.leadTime)
+		}
+		if s.firstReview != nil {
+			firstReviews = append(firstReviews, *s.firstReview)
+		}
+		if s.mergeAfterApprove != nil {
+			mergeAfterApproves = append(mergeAfterApproves, *s.mergeAfterApprove)
+		}
+		if s.pr.HasSize() {
+			sizes = append(sizes, s.pr.LinesChanged())
+		}
+	}
+
+	stats.LeadTime = newDurationStats(leadTimes)
+	stats.TimeToFirstReview = newDurationStats(firstReviews)
+	stats.TimeToMergeAfterApproval = newDurationStats(mergeAfterApproves)
+	stats.Size = newSizeStats(sizes)
+
+	return stats
+}
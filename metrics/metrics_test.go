@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompute(t *testing.T) {
+	d, err := db.NewDB(&db.Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	hello := &api.RepoData{FullName: "octocat/Hello-World"}
+	spoon := &api.RepoData{FullName: "octocat/Spoon-Knife"}
+	octocat := api.UserData{ID: 1, Login: "octocat"}
+	hubot := api.UserData{ID: 2, Login: "hubot"}
+
+	// monday of 2011-W04
+	created := time.Date(2011, 1, 24, 9, 0, 0, 0, time.UTC)
+	at := func(hours int) *time.Time {
+		t := created.Add(time.Duration(hours) * time.Hour)
+		return &t
+	}
+
+	assert.NoError(t, d.StorePullRequestBatch([]api.PullRequestData{
+		// merged a day after opening, approved 4h before merge
+		{ID: 1, User: octocat, Base: api.CommitData{Repo: hello}, CreatedAt: created,
+			ClosedAt: at(24), MergedAt: at(24), Additions: 5, Deletions: 3, Commits: 1},
+		// merged after 3 days without review
+		{ID: 2, User: octocat, Base: api.CommitData{Repo: hello}, CreatedAt: created,
+			ClosedAt: at(72), MergedAt: at(72), Additions: 400, Deletions: 50, Commits: 2},
+		// abandoned
+		{ID: 3, User: hubot, Base: api.CommitData{Repo: spoon}, CreatedAt: created, ClosedAt: at(1),
+			Additions: 2000, Commits: 1},
+		// still open the following week, size unknown
+		{ID: 4, User: hubot, Base: api.CommitData{Repo: spoon}, CreatedAt: *at(7 * 24)},
+	}))
+
+	assert.NoError(t, d.StoreReview(1, api.ReviewData{ID: 1, User: octocat, State: "COMMENTED", SubmittedAt: at(1)}))
+	assert.NoError(t, d.StoreReview(1, api.ReviewData{ID: 2, User: hubot, State: "COMMENTED", SubmittedAt: at(2)}))
+	assert.NoError(t, d.StoreReview(1, api.ReviewData{ID: 3, User: hubot, State: "APPROVED", SubmittedAt: at(20)}))
+	assert.NoError(t, d.StoreReview(1, api.ReviewData{ID: 4, User: hubot, State: "PENDING"}))
+	assert.NoError(t, d.StoreReview(4, api.ReviewData{ID: 5, User: octocat, State: "APPROVED", SubmittedAt: at(7*24 + 1)}))
+
+	report, err := Compute(d, nil)
+	assert.NoError(t, err, "Should compute metrics")
+
+	overall := report.Overall
+	assert.Equal(t, 4, overall.Opened)
+	assert.Equal(t, 2, overall.Merged)
+	assert.Equal(t, 1, overall.Abandoned)
+	assert.InDelta(t, 2.0/3, overall.MergeRate(), 0.001)
+
+	assert.Equal(t, 2, overall.LeadTime.Count)
+	assert.Equal(t, 24*time.Hour, overall.LeadTime.P50)
+	assert.Equal(t, 72*time.Hour, overall.LeadTime.P90)
+	assert.Equal(t, 48*time.Hour, overall.LeadTime.Mean)
+
+	assert.Equal(t, 2, overall.TimeToFirstReview.Count, "Authors don't review their own PRs")
+	assert.Equal(t, 1*time.Hour, overall.TimeToFirstReview.P50)
+	assert.Equal(t, 2*time.Hour, overall.TimeToFirstReview.Max)
+
+	assert.Equal(t, 1, overall.TimeToMergeAfterApproval.Count, "Unmerged PRs have no approval to merge time")
+	assert.Equal(t, 4*time.Hour, overall.TimeToMergeAfterApproval.P50)
+
+	assert.Equal(t, 3, overall.Size.Count, "Unknown sizes should be skipped")
+	assert.Equal(t, 450, overall.Size.P50)
+	assert.Equal(t, 2000, overall.Size.Max)
+	assert.Equal(t, []int{1, 0, 1, 0, 1}, bucketCounts(overall.Size))
+
+	assert.Equal(t, []string{"octocat/Hello-World", "octocat/Spoon-Knife"}, keys(report.ByRepo))
+	assert.Equal(t, 2, report.ByRepo[0].Merged)
+	assert.Equal(t, 1.0, report.ByRepo[0].MergeRate())
+	assert.Equal(t, 0.0, report.ByRepo[1].MergeRate())
+
+	assert.Equal(t, []string{"hubot", "octocat"}, keys(report.ByAuthor))
+	assert.Equal(t, []string{"2011-W04", "2011-W05"}, keys(report.ByWeek))
+	assert.Equal(t, 3, report.ByWeek[0].Opened)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, Write(buf, report))
+	assert.Contains(t, buf.String(), "octocat/Spoon-Knife")
+	assert.Contains(t, buf.String(), "67%")
+	assert.Contains(t, buf.String(), "> 1000")
+}
+
+func TestComputeFilter(t *testing.T) {
+	d, err := db.NewDB(&db.Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	assert.NoError(t, d.StorePullRequestBatch([]api.PullRequestData{{ID: 1, State: "open"}, {ID: 2, State: "closed"}}))
+
+	report, err := Compute(d, db.FilterState("open"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Overall.Opened)
+	assert.Equal(t, 0.0, report.Overall.MergeRate(), "No closed PRs should have a zero merge rate")
+	assert.Equal(t, DurationStats{}, report.Overall.LeadTime)
+}
+
+func TestRank(t *testing.T) {
+	assert.Equal(t, 0, rank(50, 1))
+	assert.Equal(t, 0, rank(50, 2))
+	assert.Equal(t, 1, rank(90, 2))
+	assert.Equal(t, 49, rank(50, 100))
+	assert.Equal(t, 94, rank(95, 100))
+	assert.Equal(t, 99, rank(100, 100))
+}
+
+func TestWeek(t *testing.T) {
+	assert.Equal(t, "2011-W04", Week(time.Date(2011, 1, 30, 23, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2020-W53", Week(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)), "Weeks belong to the ISO year")
+}
+
+func keys(stats []Stats) []string {
+	k := make([]string, 0, len(stats))
+	for _, s := range stats {
+		k = append(k, s.Key)
+	}
+	return k
+}
+
+func bucketCounts(s SizeStats) []int {
+	counts := make([]int, 0, len(s.Buckets))
+	for _, b := range s.Buckets {
+		counts = append(counts, b.Count)
+	}
+	return counts
+}
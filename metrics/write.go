@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Write writes r to w as aligned tables, overall first then by repo, author
+// and week, followed by the overall size distribution
+func Write(w io.Writer, r *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "GROUP\tKEY\tOPENED\tMERGED\tABANDONED\tMERGE RATE\t"+
+		"LEAD P50\tLEAD P90\tREVIEW P50\tREVIEW P90\tAPPROVAL TO MERGE P50\tSIZE P50\tSIZE P90")
+
+	writeStats(tw, "overall", r.Overall)
+	for _, s := range r.ByRepo {
+		writeStats(tw, "repo", s)
+	}
+	for _, s := range r.ByAuthor {
+		writeStats(tw, "author", s)
+	}
+	for _, s := range r.ByWeek {
+		writeStats(tw, "week", s)
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "SIZE\tLINES\tPRS")
+	for idx, b := range r.Overall.Size.Buckets {
+		lines := fmt.Sprintf("<= %d", b.MaxLines)
+		if idx == len(r.Overall.Size.Buckets)-1 {
+			lines = fmt.Sprintf("> %d", r.Overall.Size.Buckets[idx-1].MaxLines)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", b.Label, lines, b.Count)
+	}
+
+	return tw.Flush()
+}
+
+func writeStats(w io.Writer, group string, s Stats) {
+	key := s.Key
+	if len(key) == 0 {
+		key = "-"
+	}
+
+	mergeRate := "-"
+	if s.Merged+s.Abandoned != 0 {
+		mergeRate = fmt.Sprintf("%.0f%%", s.MergeRate()*100)
+	}
+
+	fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		group, key, s.Opened, s.Merged, s.Abandoned, mergeRate,
+		formatDuration(s.LeadTime.Count, s.LeadTime.P50),
+		formatDuration(s.LeadTime.Count, s.LeadTime.P90),
+		formatDuration(s.TimeToFirstReview.Count, s.TimeToFirstReview.P50),
+		formatDuration(s.TimeToFirstReview.Count, s.TimeToFirstReview.P90),
+		formatDuration(s.TimeToMergeAfterApproval.Count, s.TimeToMergeAfterApproval.P50),
+		formatSize(s.Size.Count, s.Size.P50),
+		formatSize(s.Size.Count, s.Size.P90))
+}
+
+// formatDuration rounds d to the unit most readable at its scale, or "-" if
+// there was nothing to measure
+func formatDuration(count int, d time.Duration) string {
+	switch {
+	case count == 0:
+		return "-"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%.1fh", d.Hours())
+	default:
+		return fmt.Sprintf("%.1fd", d.Hours()/24)
+	}
+}
+
+func formatSize(count, lines int) string {
+	if count == 0 {
+		return "-"
+	}
+
+	return fmt.Sprint(lines)
+}
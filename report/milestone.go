@@ -0,0 +1,135 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+)
+
+// MilestoneReport summarizes the pull requests attached to a single milestone
+type MilestoneReport struct {
+	// Milestone is nil for the group of PRs without a milestone
+	Milestone *api.MilestoneData
+
+	Open int
+	// Merged PRs are closed as well, but not counted in Closed
+	Merged int
+	// Closed counts PRs closed without being merged
+	Closed int
+	Total  int
+
+	Overdue bool
+}
+
+// Title returns the milestone title, or "(none)" for PRs without a milestone
+func (m MilestoneReport) Title() string {
+	if m.Milestone == nil {
+		return "(none)"
+	}
+
+	return m.Milestone.Title
+}
+
+// Milestones groups every PR stored in d by milestone. Milestones stored in d
+// are reported even without any PRs, and take precedence over the copy
+// embedded in each PR. Reports are sorted by due date, those without one last
+func Milestones(d db.DB, now time.Time) ([]MilestoneReport, error) {
+	milestones, err := d.GetAllMilestones()
+	if err != nil {
+		return nil, err
+	}
+
+	prs, err := d.GetAllPullRequests()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*MilestoneReport)
+	for idx := range milestones {
+		byID[milestones[idx].ID] = &MilestoneReport{Milestone: &milestones[idx]}
+	}
+
+	var none *MilestoneReport
+	for _, pr := range prs {
+		var r *MilestoneReport
+		if !pr.HasMilestone() {
+			if none == nil {
+				none = &MilestoneReport{}
+			}
+			r = none
+		} else {
+			var ok bool
+			if r, ok = byID[pr.Milestone.ID]; !ok {
+				r = &MilestoneReport{Milestone: pr.Milestone}
+				byID[pr.Milestone.ID] = r
+			}
+		}
+
+		r.Total++
+		switch {
+		case pr.IsMerged():
+			r.Merged++
+		case pr.IsClosed():
+			r.Closed++
+		default:
+			r.Open++
+		}
+	}
+
+	reports := make([]MilestoneReport, 0, len(byID)+1)
+	for _, r := range byID {
+		r.Overdue = r.Milestone.IsOverdue(now)
+		reports = append(reports, *r)
+	}
+	sort.Slice(reports, func(a, b int) bool {
+		return lessMilestone(reports[a].Milestone, reports[b].Milestone)
+	})
+
+	if none != nil {
+		reports = append(reports, *none)
+	}
+
+	return reports, nil
+}
+
+func lessMilestone(a, b *api.MilestoneData) bool {
+	if a.HasDueDate() != b.HasDueDate() {
+		return a.HasDueDate()
+	}
+
+	if a.HasDueDate() && !a.DueOn.Equal(*b.DueOn) {
+		return a.DueOn.Before(*b.DueOn)
+	}
+
+	if a.Title != b.Title {
+		return a.Title < b.Title
+	}
+
+	return a.ID < b.ID
+}
+
+// WriteMilestones writes reports to w as an aligned table
+func WriteMilestones(w io.Writer, reports []MilestoneReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "MILESTONE\tREPO\tDUE\tOPEN\tMERGED\tCLOSED\tTOTAL\tOVERDUE")
+	for _, r := range reports {
+		var repo, due string
+		if r.Milestone != nil {
+			repo = r.Milestone.RepoFullName()
+			if r.Milestone.HasDueDate() {
+				due = r.Milestone.DueOn.Format("2006-01-02")
+			}
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%t\n",
+			r.Title(), repo, due, r.Open, r.Merged, r.Closed, r.Total, r.Overdue)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMilestones(t *testing.T) {
+	d, err := db.NewDB(&db.Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-24 * time.Hour)
+	future := now.Add(24 * time.Hour)
+
+	v1 := api.MilestoneData{ID: 1, Title: "v1.0", State: "open", DueOn: &past}
+	v2 := api.MilestoneData{ID: 2, Title: "v2.0", State: "open", DueOn: &future}
+	someday := api.MilestoneData{ID: 3, Title: "someday", State: "open"}
+	assert.NoError(t, d.StoreMilestoneBatch([]api.MilestoneData{someday, v2, v1}))
+
+	assert.NoError(t, d.StorePullRequestBatch([]api.PullRequestData{
+		{ID: 1, Milestone: &v1},
+		{ID: 2, Milestone: &v1, ClosedAt: &past, MergedAt: &past},
+		{ID: 3, Milestone: &v1, ClosedAt: &past},
+		{ID: 4, Milestone: &v2},
+		{ID: 5},
+	}))
+
+	reports, err := Milestones(d, now)
+	assert.NoError(t, err, "Should build milestone report")
+	assert.Equal(t, 4, len(reports))
+
+	assert.Equal(t, "v1.0", reports[0].Title(), "Earliest due date should be first")
+	assert.Equal(t, 1, reports[0].Open)
+	assert.Equal(t, 1, reports[0].Merged)
+	assert.Equal(t, 1, reports[0].Closed)
+	assert.Equal(t, 3, reports[0].Total)
+	assert.True(t, reports[0].Overdue, "v1.0 is past due")
+
+	assert.Equal(t, "v2.0", reports[1].Title())
+	assert.False(t, reports[1].Overdue, "v2.0 is not yet due")
+
+	assert.Equal(t, "someday", reports[2].Title(), "Milestones without a due date should be last")
+	assert.Equal(t, 0, reports[2].Total, "Milestones without PRs should still be reported")
+
+	assert.Nil(t, reports[3].Milestone, "PRs without a milestone should be grouped last")
+	assert.Equal(t, 1, reports[3].Open)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, WriteMilestones(buf, reports))
+	assert.Contains(t, buf.String(), "(none)")
+}
@@ -0,0 +1,202 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+)
+
+// OwnerReviewReport is an open pull request lacking approval from code owners
+// required to review it
+type OwnerReviewReport struct {
+	PullRequest api.PullRequestData
+
+	// Required are every code owner of the files the pull request changes
+	Required []string
+	// Missing are the required owners who haven't approved it. A team has
+	// approved once any of its members has
+	Missing []string
+}
+
+// RepoFullName returns the owner/name of the base repo of the pull request
+func (o OwnerReviewReport) RepoFullName() string {
+	if !o.PullRequest.Base.HasRepo() {
+		return ""
+	}
+
+	return o.PullRequest.Base.Repo.FullName
+}
+
+// OwnerReviews reports every open pull request stored in d whose required
+// owners haven't all approved it. Teams are matched by slug against the teams
+// stored, and emails against the users stored. Reports are sorted by repo then
+// number
+func OwnerReviews(d db.DB) ([]OwnerReviewReport, error) {
+	prs, err := d.GetAllPullRequests()
+	if err != nil {
+		return nil, err
+	}
+
+	owners, err := newOwnerIndex(d)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]OwnerReviewReport, 0)
+	for _, pr := range prs {
+		if pr.State != "open" {
+			continue
+		}
+
+		required, ok, err := d.GetRequiredOwners(pr.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || len(required) == 0 {
+			continue
+		}
+
+		reviews, err := d.GetReviews(pr.ID)
+		if err != nil {
+			return nil, err
+		}
+		approved, reviewers := approvers(reviews)
+
+		missing := make([]string, 0)
+		for _, owner := range required {
+			if !owners.approved(owner, approved, reviewers) {
+				missing = append(missing, owner)
+			}
+		}
+
+		if len(missing) != 0 {
+			reports = append(reports, OwnerReviewReport{
+				PullRequest: pr,
+				Required:    required,
+				Missing:     missing,
+			})
+		}
+	}
+
+	sort.Slice(reports, func(a, b int) bool {
+		if reports[a].RepoFullName() != reports[b].RepoFullName() {
+			return reports[a].RepoFullName() < reports[b].RepoFullName()
+		}
+		return reports[a].PullRequest.Number < reports[b].PullRequest.Number
+	})
+
+	return reports, nil
+}
+
+// approvers returns the IDs of the users whose latest review approves, as a
+// later review requesting changes withdraws an approval, and the IDs of every
+// reviewer by login. Comments neither approve nor withdraw
+func approvers(reviews []api.ReviewData) (map[int]bool, map[string]int) {
+	sorted := make([]api.ReviewData, len(reviews))
+	copy(sorted, reviews)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return sorted[a].SubmittedBefore(sorted[b])
+	})
+
+	approved := make(map[int]bool)
+	reviewers := make(map[string]int)
+	for _, review := range sorted {
+		reviewers[strings.ToLower(review.User.Login)] = review.User.ID
+
+		switch review.State {
+		case "APPROVED":
+			approved[review.User.ID] = true
+		case "CHANGES_REQUESTED", "DISMISSED":
+			approved[review.User.ID] = false
+		}
+	}
+
+	return approved, reviewers
+}
+
+// ownerIndex resolves code owners to the IDs of the users who can approve for
+// them
+type ownerIndex struct {
+	logins map[string]int
+	emails map[string]int
+	teams  map[string][]int
+}
+
+func newOwnerIndex(d db.DB) (*ownerIndex, error) {
+	idx := &ownerIndex{
+		logins: make(map[string]int),
+		emails: make(map[string]int),
+		teams:  make(map[string][]int),
+	}
+
+	users, err := d.GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		idx.logins[strings.ToLower(u.Login)] = u.ID
+		if len(u.Email) != 0 {
+			idx.emails[strings.ToLower(u.Email)] = u.ID
+		}
+	}
+
+	teams, err := d.GetAllTeams()
+	if err != nil {
+		return nil, err
+	}
+	for _, team := range teams {
+		members, err := d.GetTeamMemberIDs(team.ID)
+		if err != nil {
+			return nil, err
+		}
+		idx.teams[strings.ToLower(team.Slug)] = members
+	}
+
+	return idx, nil
+}
+
+// approved reports whether owner has approved. Logins of users without a
+// stored profile are matched against the reviewers' logins
+func (o *ownerIndex) approved(owner string, approved map[int]bool, reviewers map[string]int) bool {
+	name := strings.ToLower(owner)
+
+	if !strings.HasPrefix(name, "@") {
+		id, ok := o.emails[name]
+		return ok && approved[id]
+	}
+	name = strings.TrimPrefix(name, "@")
+
+	if idx := strings.Index(name, "/"); idx >= 0 {
+		for _, id := range o.teams[name[idx+1:]] {
+			if approved[id] {
+				return true
+			}
+		}
+		return false
+	}
+
+	id, ok := o.logins[name]
+	if !ok {
+		id, ok = reviewers[name]
+	}
+	return ok && approved[id]
+}
+
+// WriteOwnerReviews writes reports to w as an aligned table
+func WriteOwnerReviews(w io.Writer, reports []OwnerReviewReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "REPO\tNUMBER\tMISSING\tREQUIRED\tTITLE")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n",
+			r.RepoFullName(), r.PullRequest.Number, strings.Join(r.Missing, ","),
+			strings.Join(r.Required, ","), r.PullRequest.Title)
+	}
+
+	return tw.Flush()
+}
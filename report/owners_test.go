@@ -0,0 +1,72 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnerReviews(t *testing.T) {
+	d, err := db.NewDB(&db.Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	hello := &api.RepoData{ID: 1296269, FullName: "octocat/Hello-World"}
+	octocat := api.UserData{ID: 1, Login: "octocat"}
+	hubot := api.UserData{ID: 2, Login: "hubot"}
+	monalisa := api.UserData{ID: 3, Login: "monalisa"}
+
+	assert.NoError(t, d.StorePullRequestBatch([]api.PullRequestData{
+		{ID: 1, Number: 3, State: "open", Base: api.CommitData{Repo: hello}},
+		{ID: 2, Number: 2, State: "open", Base: api.CommitData{Repo: hello}},
+		{ID: 3, Number: 1, State: "open", Base: api.CommitData{Repo: hello}},
+		{ID: 4, Number: 4, State: "closed", Base: api.CommitData{Repo: hello}},
+		{ID: 5, Number: 5, State: "open", Base: api.CommitData{Repo: hello}},
+	}))
+	assert.NoError(t, d.StoreTeam(api.TeamData{ID: 1, Slug: "justice-league"}, []api.UserData{hubot}))
+	assert.NoError(t, d.StoreUser(api.UserProfileData{UserData: monalisa, Email: "mona@example.com"}))
+
+	at := func(hours int) *time.Time {
+		t := time.Date(2011, 1, 26, hours, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	// every owner approved
+	assert.NoError(t, d.StoreRequiredOwners(1, []string{"@octocat", "@github/justice-league", "mona@example.com"}))
+	assert.NoError(t, d.StoreReview(1, api.ReviewData{ID: 1, User: octocat, State: "APPROVED", SubmittedAt: at(1)}))
+	assert.NoError(t, d.StoreReview(1, api.ReviewData{ID: 2, User: hubot, State: "APPROVED", SubmittedAt: at(1)}))
+	assert.NoError(t, d.StoreReview(1, api.ReviewData{ID: 3, User: monalisa, State: "APPROVED", SubmittedAt: at(1)}))
+
+	// octocat's approval is withdrawn, and the team hasn't reviewed
+	assert.NoError(t, d.StoreRequiredOwners(2, []string{"@github/justice-league", "@OctoCat"}))
+	assert.NoError(t, d.StoreReview(2, api.ReviewData{ID: 4, User: octocat, State: "CHANGES_REQUESTED", SubmittedAt: at(2)}))
+	assert.NoError(t, d.StoreReview(2, api.ReviewData{ID: 5, User: octocat, State: "APPROVED", SubmittedAt: at(1)}))
+	assert.NoError(t, d.StoreReview(2, api.ReviewData{ID: 6, User: monalisa, State: "APPROVED", SubmittedAt: at(1)}))
+
+	// only commented
+	assert.NoError(t, d.StoreRequiredOwners(3, []string{"mona@example.com"}))
+	assert.NoError(t, d.StoreReview(3, api.ReviewData{ID: 7, User: monalisa, State: "COMMENTED", SubmittedAt: at(1)}))
+
+	// closed PRs and PRs without owners aren't reported
+	assert.NoError(t, d.StoreRequiredOwners(4, []string{"@octocat"}))
+	assert.NoError(t, d.StoreRequiredOwners(5, []string{}))
+
+	reports, err := OwnerReviews(d)
+	assert.NoError(t, err, "Should build owner review report")
+	assert.Equal(t, 2, len(reports))
+
+	assert.Equal(t, 1, reports[0].PullRequest.Number, "Reports should be sorted by number")
+	assert.Equal(t, []string{"mona@example.com"}, reports[0].Missing)
+
+	assert.Equal(t, 2, reports[1].PullRequest.Number)
+	assert.Equal(t, []string{"@github/justice-league", "@OctoCat"}, reports[1].Missing)
+	assert.Equal(t, []string{"@github/justice-league", "@OctoCat"}, reports[1].Required)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, WriteOwnerReviews(buf, reports))
+	assert.Contains(t, buf.String(), "octocat/Hello-World  2       @github/justice-league,@OctoCat")
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+)
+
+// PullRequestReport is a pull request along with details of its author taken
+// from the users and teams stored in the DB
+type PullRequestReport struct {
+	PullRequest api.PullRequestData
+
+	// Author is the display name of the author if their profile is stored,
+	// otherwise their login
+	Author string
+	// Teams are the slugs of the teams the author belongs to
+	Teams []string
+}
+
+// RepoFullName returns the owner/name of the base repo of the pull request
+func (p PullRequestReport) RepoFullName() string {
+	if !p.PullRequest.Base.HasRepo() {
+		return ""
+	}
+
+	return p.PullRequest.Base.Repo.FullName
+}
+
+// PullRequests builds a report of every pull request stored in d matching f
+func PullRequests(d db.DB, f db.PRFilterFunc) ([]PullRequestReport, error) {
+	prs, err := d.GetFilterPullRequests(f)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]PullRequestReport, 0, len(prs))
+	for _, pr := range prs {
+		r := PullRequestReport{
+			PullRequest: pr,
+			Author:      pr.User.Login,
+			Teams:       make([]string, 0),
+		}
+
+		profile, ok, err := d.GetUserByID(pr.User.ID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			r.Author = profile.DisplayName()
+		}
+
+		teams, err := d.GetTeamsByUserID(pr.User.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, team := range teams {
+			r.Teams = append(r.Teams, team.Slug)
+		}
+
+		reports = append(reports, r)
+	}
+
+	return reports, nil
+}
+
+// WritePullRequests writes reports to w as an aligned table
+func WritePullRequests(w io.Writer, reports []PullRequestReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "REPO\tNUMBER\tSTATE\tAUTHOR\tTEAMS\tTITLE")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n",
+			r.RepoFullName(), r.PullRequest.Number, r.PullRequest.State,
+			r.Author, strings.Join(r.Teams, ","), r.PullRequest.Title)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,44 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullRequests(t *testing.T) {
+	d, err := db.NewDB(&db.Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	octocat := api.UserData{Login: "octocat", ID: 1}
+	hubot := api.UserData{Login: "hubot", ID: 2}
+
+	assert.NoError(t, d.StoreUser(api.UserProfileData{UserData: octocat, Name: "monalisa octocat"}))
+	assert.NoError(t, d.StoreTeam(api.TeamData{ID: 1, Slug: "justice-league"}, []api.UserData{octocat}))
+	assert.NoError(t, d.StoreTeam(api.TeamData{ID: 2, Slug: "avengers"}, []api.UserData{octocat, hubot}))
+
+	assert.NoError(t, d.StorePullRequestBatch([]api.PullRequestData{
+		{ID: 1, Number: 1347, Title: "new-feature", User: octocat,
+			Base: api.CommitData{Repo: &api.RepoData{FullName: "octocat/Hello-World"}}},
+		{ID: 2, Number: 1348, Title: "orphaned-feature", User: api.UserData{Login: "ghost", ID: 10137}},
+	}))
+
+	reports, err := PullRequests(d, nil)
+	assert.NoError(t, err, "Should build PR report")
+	assert.Equal(t, 2, len(reports))
+
+	assert.Equal(t, "monalisa octocat", reports[0].Author, "Stored profile should give the display name")
+	assert.Equal(t, []string{"avengers", "justice-league"}, reports[0].Teams)
+	assert.Equal(t, "octocat/Hello-World", reports[0].RepoFullName())
+
+	assert.Equal(t, "ghost", reports[1].Author, "Unknown users should fall back to their login")
+	assert.Equal(t, []string{}, reports[1].Teams)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, WritePullRequests(buf, reports))
+	assert.Contains(t, buf.String(), "avengers,justice-league")
+}
@@ -0,0 +1,314 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Target is a user or org whose pull requests are synced on an interval
+type Target struct {
+	// Name identifies the target in logs and status, defaulting to the user
+	// or org
+	Name   string `json:"name"`
+	User   string `json:"user,omitempty"`
+	Org    string `json:"org,omitempty"`
+	Search string `json:"search,omitempty"`
+	// Interval between the start of each sync, defaulting to the
+	// scheduler's interval
+	Interval time.Duration `json:"interval"`
+}
+
+// SyncFunc syncs a single target. It should return promptly once ctx is done
+type SyncFunc func(ctx context.Context, target Target) error
+
+// Args configures the Scheduler
+type Args struct {
+	Targets []Target
+	Sync    SyncFunc
+
+	// Interval is used by targets which don't set their own
+	Interval time.Duration
+	// Jitter is the most each sync is randomly delayed by, so targets sharing
+	// an interval don't all hit github at once
+	Jitter time.Duration
+
+	// StatusFile, if set, is where the status of each target is persisted
+	StatusFile string
+
+	Logger *logrus.Logger
+}
+
+func (a *Args) validate() error {
+	if len(a.Targets) == 0 {
+		return argMissingError("Targets")
+	}
+
+	if a.Sync == nil {
+		return argMissingError("Sync")
+	}
+
+	if a.Logger == nil {
+		return argMissingError("Logger")
+	}
+
+	if a.Jitter < 0 {
+		return fmt.Errorf("Jitter must not be negative")
+	}
+
+	names := make(map[string]bool)
+	for idx := range a.Targets {
+		t := &a.Targets[idx]
+		if len(t.User) != 0 && len(t.Org) != 0 {
+			return fmt.Errorf("target %d: either User or Org may be set, not both", idx)
+		}
+
+		if len(t.Name) == 0 {
+			t.Name = t.User + t.Org
+		}
+		if len(t.Name) == 0 {
+			return fmt.Errorf("target %d: Name must be set when neither User nor Org are", idx)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("target %s: names must be unique", t.Name)
+		}
+		names[t.Name] = true
+
+		if t.Interval == 0 {
+			t.Interval = a.Interval
+		}
+		if t.Interval <= 0 {
+			return fmt.Errorf("target %s: Interval must be set", t.Name)
+		}
+	}
+
+	return nil
+}
+
+// TargetStatus reports how the syncs of a target have gone
+type TargetStatus struct {
+	Target Target `json:"target"`
+
+	Running bool `json:"running"`
+	Runs    int  `json:"runs"`
+	// Skipped counts syncs which were due while the previous one was still
+	// running
+	Skipped int `json:"skipped"`
+
+	LastStart   time.Time `json:"last_start"`
+	LastEnd     time.Time `json:"last_end"`
+	LastSuccess time.Time `json:"last_success"`
+	LastError   string    `json:"last_error,omitempty"`
+
+	// synced is whether a sync has succeeded since this process started,
+	// as opposed to one persisted from a previous process
+	synced bool
+}
+
+// Scheduler runs the sync of each target on its interval
+type Scheduler struct {
+	targets    []Target
+	sync       SyncFunc
+	jitter     time.Duration
+	statusFile string
+	logger     *logrus.Entry
+
+	mu       sync.Mutex
+	status   map[string]*TargetStatus
+	rand     *rand.Rand
+	running  bool
+	inFlight sync.WaitGroup
+
+	// saveMu is held from taking a snapshot of the status until it's saved,
+	// so an older snapshot never replaces a newer one
+	saveMu sync.Mutex
+}
+
+// NewScheduler returns a new Scheduler, loading the status of each target from
+// the status file if it exists
+func NewScheduler(args *Args) (*Scheduler, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Scheduler{
+		targets:    args.Targets,
+		sync:       args.Sync,
+		jitter:     args.Jitter,
+		statusFile: args.StatusFile,
+		logger:     args.Logger.WithFields(logrus.Fields{"prefix": "Scheduler"}),
+		status:     make(map[string]*TargetStatus),
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for _, t := range s.targets {
+		s.status[t.Name] = &TargetStatus{Target: t}
+	}
+
+	if err := s.loadStatus(); err != nil {
+		return nil, errors.Wrap(err, "loading status file")
+	}
+
+	return s, nil
+}
+
+// Run syncs every target immediately, then on its interval until ctx is done.
+// It waits for syncs in flight to return before returning itself
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return fmt.Errorf("scheduler is already running")
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	var loops sync.WaitGroup
+	for _, t := range s.targets {
+		loops.Add(1)
+		go func(t Target) {
+			defer loops.Done()
+			s.loop(ctx, t)
+		}(t)
+	}
+
+	loops.Wait()
+	s.inFlight.Wait()
+
+	return nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, t Target) {
+	// the first sync only waits for jitter
+	timer := time.NewTimer(s.delay(0))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		// select picks at random when both are ready, so don't start a sync
+		// after ctx is done
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.trigger(ctx, t)
+		timer.Reset(s.delay(t.Interval))
+	}
+}
+
+func (s *Scheduler) delay(interval time.Duration) time.Duration {
+	if s.jitter <= 0 {
+		return interval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return interval + time.Duration(s.rand.Int63n(int64(s.jitter)))
+}
+
+// trigger starts a sync of t, unless the previous one is still running
+func (s *Scheduler) trigger(ctx context.Context, t Target) {
+	s.mu.Lock()
+	status := s.status[t.Name]
+	if status.Running {
+		status.Skipped++
+		s.mu.Unlock()
+		s.logger.Warnf("skipping sync of %s, the previous sync is still running", t.Name)
+		return
+	}
+	status.Running = true
+	status.LastStart = time.Now()
+	s.mu.Unlock()
+
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+
+		s.logger.Infof("syncing %s", t.Name)
+		err := s.sync(ctx, t)
+
+		s.mu.Lock()
+		status.Running = false
+		status.Runs++
+		status.LastEnd = time.Now()
+		if err != nil {
+			status.LastError = err.Error()
+			s.logger.Errorf("syncing %s - %v", t.Name, err)
+		} else {
+			status.LastError = ""
+			status.LastSuccess = status.LastEnd
+			status.synced = true
+			s.logger.Infof("synced %s in %s", t.Name, status.LastEnd.Sub(status.LastStart))
+		}
+		s.mu.Unlock()
+
+		if err := s.saveStatus(); err != nil {
+			s.logger.Errorf("saving status file - %v", err)
+		}
+	}()
+}
+
+// Status returns the status of every target, sorted by name
+func (s *Scheduler) Status() []TargetStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.statusLocked()
+}
+
+func (s *Scheduler) statusLocked() []TargetStatus {
+	statuses := make([]TargetStatus, 0, len(s.status))
+	for _, status := range s.status {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(a, b int) bool {
+		return statuses[a].Target.Name < statuses[b].Target.Name
+	})
+
+	return statuses
+}
+
+// Healthy reports whether the scheduler is running
+func (s *Scheduler) Healthy() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.running
+}
+
+// Ready reports whether every target has synced successfully since the
+// scheduler started, so the DB holds a full set of data
+func (s *Scheduler) Ready() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, status := range s.status {
+		if !status.synced {
+			return false
+		}
+	}
+
+	return true
+}
+
+func argMissingError(field string) error {
+	return fmt.Errorf("%s must be set in scheduler Args", field)
+}
@@ -0,0 +1,273 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func noopSync(ctx context.Context, target Target) error {
+	return nil
+}
+
+func TestNewSchedulerValidate(t *testing.T) {
+	logger := logrus.New()
+
+	_, err := NewScheduler(&Args{Sync: noopSync, Logger: logger, Interval: time.Minute})
+	assert.EqualError(t, err, "Targets must be set in scheduler Args")
+
+	_, err = NewScheduler(&Args{Targets: []Target{{Org: "coreos"}}, Logger: logger, Interval: time.Minute})
+	assert.EqualError(t, err, "Sync must be set in scheduler Args")
+
+	_, err = NewScheduler(&Args{Targets: []Target{{Org: "coreos"}}, Sync: noopSync, Logger: logger})
+	assert.EqualError(t, err, "target coreos: Interval must be set")
+
+	_, err = NewScheduler(&Args{Targets: []Target{{Org: "coreos", User: "octocat"}}, Sync: noopSync, Logger: logger, Interval: time.Minute})
+	assert.Error(t, err)
+
+	_, err = NewScheduler(&Args{Targets: []Target{{Org: "coreos"}, {Org: "coreos"}}, Sync: noopSync, Logger: logger, Interval: time.Minute})
+	assert.EqualError(t, err, "target coreos: names must be unique")
+
+	s, err := NewScheduler(&Args{
+		Targets:  []Target{{Org: "coreos"}, {User: "octocat", Interval: time.Hour}},
+		Sync:     noopSync,
+		Logger:   logger,
+		Interval: time.Minute,
+	})
+	assert.NoError(t, err)
+
+	statuses := s.Status()
+	assert.Equal(t, Target{Name: "coreos", Org: "coreos", Interval: time.Minute}, statuses[0].Target)
+	assert.Equal(t, Target{Name: "octocat", User: "octocat", Interval: time.Hour}, statuses[1].Target)
+}
+
+func TestRunSyncsPeriodically(t *testing.T) {
+	var mu sync.Mutex
+	synced := make(map[string]int)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := NewScheduler(&Args{
+		Targets: []Target{{Org: "coreos"}, {User: "octocat"}},
+		Sync: func(ctx context.Context, target Target) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			synced[target.Name]++
+			if synced["coreos"] >= 3 && synced["octocat"] >= 3 {
+				cancel()
+			}
+			if target.Name == "octocat" {
+				return fmt.Errorf("rate limited")
+			}
+			return nil
+		},
+		Interval: time.Millisecond,
+		Jitter:   time.Millisecond,
+		Logger:   logrus.New(),
+	})
+	assert.NoError(t, err)
+	assert.False(t, s.Healthy())
+	assert.False(t, s.Ready())
+
+	assert.NoError(t, s.Run(ctx))
+	assert.False(t, s.Healthy())
+	// octocat never synced successfully
+	assert.False(t, s.Ready())
+
+	statuses := s.Status()
+	assert.True(t, statuses[0].Runs >= 3)
+	assert.Empty(t, statuses[0].LastError)
+	assert.False(t, statuses[0].LastSuccess.IsZero())
+	assert.True(t, statuses[1].Runs >= 3)
+	assert.Equal(t, "rate limited", statuses[1].LastError)
+	assert.True(t, statuses[1].LastSuccess.IsZero())
+}
+
+func TestRunSkipsActiveTarget(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+
+	s, err := NewScheduler(&Args{
+		Targets: []Target{{Org: "coreos"}},
+		Sync: func(ctx context.Context, target Target) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		},
+		Interval: time.Millisecond,
+		Logger:   logrus.New(),
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	<-started
+	assert.True(t, s.Healthy())
+	assert.False(t, s.Ready())
+
+	// let a few syncs come due while the first is still running
+	for s.Status()[0].Skipped < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.True(t, s.Status()[0].Running)
+
+	cancel()
+	close(release)
+	assert.NoError(t, <-done)
+
+	status := s.Status()[0]
+	assert.Equal(t, 1, status.Runs)
+	assert.False(t, status.Running)
+	assert.True(t, s.Ready())
+}
+
+func TestStatusFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	statusFile := filepath.Join(dir, "status.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	args := &Args{
+		Targets: []Target{{Org: "coreos"}},
+		Sync: func(ctx context.Context, target Target) error {
+			cancel()
+			return fmt.Errorf("rate limited")
+		},
+		Interval:   time.Hour,
+		StatusFile: statusFile,
+		Logger:     logrus.New(),
+	}
+
+	s, err := NewScheduler(args)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Run(ctx))
+
+	// a new process picks up where the last left off, but isn't ready until
+	// it has synced itself
+	args.Targets = []Target{{Org: "coreos", Interval: time.Minute}, {User: "octocat"}}
+	s, err = NewScheduler(args)
+	assert.NoError(t, err)
+
+	statuses := s.Status()
+	assert.Equal(t, 1, statuses[0].Runs)
+	assert.Equal(t, "rate limited", statuses[0].LastError)
+	assert.Equal(t, time.Minute, statuses[0].Target.Interval)
+	assert.Equal(t, 0, statuses[1].Runs)
+	assert.False(t, s.Ready())
+
+	// the temporary file was renamed into place
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	assert.NoError(t, ioutil.WriteFile(statusFile, []byte("{"), 0644))
+	_, err = NewScheduler(args)
+	assert.Error(t, err)
+}
+
+func TestSaveStatusConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	statusFile := filepath.Join(dir, "status.json")
+
+	s, err := NewScheduler(&Args{
+		Targets:    []Target{{Org: "coreos"}, {User: "octocat"}},
+		Sync:       noopSync,
+		Interval:   time.Hour,
+		StatusFile: statusFile,
+		Logger:     logrus.New(),
+	})
+	assert.NoError(t, err)
+
+	// each run saves after updating its target, as a sync does
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			s.mu.Lock()
+			s.status[name].Runs++
+			s.mu.Unlock()
+
+			assert.NoError(t, s.saveStatus())
+		}([]string{"coreos", "octocat"}[i%2])
+	}
+	wg.Wait()
+
+	buf, err := ioutil.ReadFile(statusFile)
+	assert.NoError(t, err)
+
+	saved := make([]TargetStatus, 0)
+	assert.NoError(t, json.Unmarshal(buf, &saved))
+	if assert.Len(t, saved, 2) {
+		assert.Equal(t, 25, saved[0].Runs, "The last save should hold every run")
+		assert.Equal(t, 25, saved[1].Runs, "The last save should hold every run")
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	release := make(chan struct{})
+	s, err := NewScheduler(&Args{
+		Targets: []Target{{Org: "coreos"}},
+		Sync: func(ctx context.Context, target Target) error {
+			<-release
+			return nil
+		},
+		Interval: time.Hour,
+		Logger:   logrus.New(),
+	})
+	assert.NoError(t, err)
+
+	check := func(h http.Handler, code int) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, code, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), `"name":"coreos"`)
+	}
+
+	check(s.HealthHandler(), http.StatusServiceUnavailable)
+	check(s.ReadyHandler(), http.StatusServiceUnavailable)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	for !s.Healthy() {
+		time.Sleep(time.Millisecond)
+	}
+	check(s.HealthHandler(), http.StatusOK)
+	check(s.ReadyHandler(), http.StatusServiceUnavailable)
+
+	close(release)
+	for !s.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+	check(s.ReadyHandler(), http.StatusOK)
+
+	cancel()
+	assert.NoError(t, <-done)
+	check(s.HealthHandler(), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// loadStatus restores the status of every known target from the status file.
+// Targets which are no longer configured are dropped
+func (s *Scheduler) loadStatus() error {
+	if len(s.statusFile) == 0 {
+		return nil
+	}
+
+	buf, err := ioutil.ReadFile(s.statusFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	saved := make([]TargetStatus, 0)
+	if err := json.Unmarshal(buf, &saved); err != nil {
+		return err
+	}
+
+	for _, status := range saved {
+		current, ok := s.status[status.Target.Name]
+		if !ok {
+			continue
+		}
+
+		// the configured target wins over the one saved
+		status.Target = current.Target
+		status.Running = false
+		*current = status
+	}
+
+	return nil
+}
+
+// saveStatus writes the status of every target to the status file, replacing
+// it atomically so a crash never leaves it half written
+func (s *Scheduler) saveStatus() error {
+	if len(s.statusFile) == 0 {
+		return nil
+	}
+
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	buf, err := json.MarshalIndent(s.Status(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.statusFile), ".gogitpr-status")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.statusFile)
+}
+
+// HealthHandler responds 200 while the scheduler is running, and 503 once it
+// has stopped. The status of every target is included in the body
+func (s *Scheduler) HealthHandler() http.Handler {
+	return s.statusHandler(s.Healthy)
+}
+
+// ReadyHandler responds 200 once every target has synced successfully, and
+// 503 until then. The status of every target is included in the body
+func (s *Scheduler) ReadyHandler() http.Handler {
+	return s.statusHandler(s.Ready)
+}
+
+func (s *Scheduler) statusHandler(ok func() bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := json.Marshal(s.Status())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !ok() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write(body)
+	})
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/doodles526/gogitpr/server"
+	"github.com/doodles526/gogitpr/telemetry"
+	"github.com/doodles526/gogitpr/webhook"
+	"github.com/pkg/errors"
+)
+
+// runServe syncs pull requests once, then serves them until interrupted,
+// keeping them up to date from webhooks if a secret is configured
+func runServe(s *syncer, reg *telemetry.Registry) error {
+	if err := s.syncAll(); err != nil {
+		return err
+	}
+
+	srv, err := newServer(s, reg)
+	if err != nil {
+		return err
+	}
+
+	return srv.ListenAndServe(signalContext())
+}
+
+// newServer returns a server of the PRs synced by s, exposing the metrics of
+// reg at /metrics
+func newServer(s *syncer, reg *telemetry.Registry) (*server.Server, error) {
+	serverArgs := &server.Args{
+		Addr:   s.cfg.ListenAddr,
+		DB:     s.db,
+		Logger: s.cfg.Logger,
+	}
+
+	if len(s.cfg.WebhookSecret) != 0 {
+		hook, err := webhook.NewHandler(&webhook.Args{
+			Secret:    s.cfg.WebhookSecret,
+			DB:        s.db,
+			GithubAPI: s.gh,
+			Logger:    s.cfg.Logger,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "creating webhook handler")
+		}
+		serverArgs.Webhook = hook
+	}
+
+	srv, err := server.NewServer(serverArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating server")
+	}
+	srv.Handle("/metrics", reg.Handler())
+
+	return srv, nil
+}
+
+// signalContext returns a context which is done upon SIGINT or SIGTERM
+func signalContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigc
+		cancel()
+	}()
+
+	return ctx
+}
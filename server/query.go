@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+)
+
+// parseFilter builds a filter from the query parameters of a /pulls request.
+// Every parameter given must match
+func parseFilter(d db.DB, query url.Values) (db.PRFilterFunc, error) {
+	filters := make([]db.PRFilterFunc, 0)
+
+	if state := query.Get("state"); len(state) != 0 && state != "all" {
+		if state != "open" && state != "closed" {
+			return nil, fmt.Errorf("state must be one of open, closed or all")
+		}
+		filters = append(filters, db.FilterState(state))
+	}
+
+	if repo := query.Get("repo"); len(repo) != 0 {
+		filters = append(filters, db.FilterRepo(repo))
+	}
+
+	if owner := query.Get("owner"); len(owner) != 0 {
+		filters = append(filters, db.FilterBaseOwner(owner))
+	}
+
+	if author := query.Get("author"); len(author) != 0 {
+		filters = append(filters, db.FilterAuthor(author))
+	}
+
+	if assignee := query.Get("assignee"); len(assignee) != 0 {
+		filters = append(filters, db.FilterAssignee(assignee))
+	}
+
+	if milestone := query.Get("milestone"); len(milestone) != 0 {
+		filters = append(filters, db.FilterMilestone(milestone))
+	}
+
+	if merged := query.Get("merged"); len(merged) != 0 {
+		want, err := strconv.ParseBool(merged)
+		if err != nil {
+			return nil, fmt.Errorf("merged must be true or false")
+		}
+		filters = append(filters, func(pr api.PullRequestData) (bool, error) {
+			return pr.IsMerged() == want, nil
+		})
+	}
+
+	if ci, ok := query["ci"]; ok {
+		state := api.CIState(ci[0])
+		switch state {
+		case api.CIStateNone, api.CIStateSuccess, api.CIStateFailure, api.CIStatePending:
+		default:
+			return nil, fmt.Errorf("ci must be one of success, failure, pending or empty")
+		}
+		filters = append(filters, db.FilterCIState(d, state))
+	}
+
+	return db.FilterAnd(filters...), nil
+}
+
+// Stats aggregates the pull requests stored in the DB
+type Stats struct {
+	Total int `json:"total"`
+	Open  int `json:"open"`
+	// Closed counts PRs closed without being merged
+	Closed int `json:"closed"`
+	Merged int `json:"merged"`
+
+	// ByRepo counts PRs by the full name of their base repo
+	ByRepo map[string]int `json:"by_repo"`
+	// ByCIState counts open PRs by their stored CI state, "none" if unknown
+	ByCIState map[string]int `json:"by_ci_state"`
+}
+
+func computeStats(d db.DB) (*Stats, error) {
+	prs, err := d.GetAllPullRequests()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{
+		ByRepo:    make(map[string]int),
+		ByCIState: make(map[string]int),
+	}
+
+	for _, pr := range prs {
+		stats.Total++
+		if pr.Base.HasRepo() {
+			stats.ByRepo[pr.Base.Repo.FullName]++
+		}
+
+		switch {
+		case pr.IsMerged():
+			stats.Merged++
+		case pr.State == "closed":
+			stats.Closed++
+		default:
+			stats.Open++
+
+			state, _, err := d.GetCIState(pr.ID)
+			if err != nil {
+				return nil, err
+			}
+			if state == api.CIStateNone {
+				state = "none"
+			}
+			stats.ByCIState[string(state)]++
+		}
+	}
+
+	return stats, nil
+}
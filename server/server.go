@@ -0,0 +1,392 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPerPage = 30
+	maxPerPage     = 100
+
+	shutdownTimeout = 10 * time.Second
+)
+
+// Args configures the Server
+type Args struct {
+	// Addr is the address to listen on, e.g. ":8080"
+	Addr   string
+	DB     db.DB
+	Logger *logrus.Logger
+
+	// Webhook, if set, is served at /webhook
+	Webhook http.Handler
+}
+
+func (a *Args) validate() error {
+	if len(a.Addr) == 0 {
+		return argMissingError("Addr")
+	}
+
+	if a.DB == nil {
+		return argMissingError("DB")
+	}
+
+	if a.Logger == nil {
+		return argMissingError("Logger")
+	}
+
+	return nil
+}
+
+// Server exposes the pull requests stored in a DB over an HTTP JSON API
+type Server struct {
+	addr   string
+	db     db.DB
+	mux    *http.ServeMux
+	logger *logrus.Entry
+}
+
+// NewServer returns a new Server
+func NewServer(args *Args) (*Server, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		addr:   args.Addr,
+		db:     args.DB,
+		mux:    http.NewServeMux(),
+		logger: args.Logger.WithFields(logrus.Fields{"prefix": "Server"}),
+	}
+
+	s.mux.HandleFunc("/pulls", s.handlePulls)
+	s.mux.HandleFunc("/pulls/", s.handlePullByID)
+	s.mux.HandleFunc("/repos", s.handleRepos)
+	s.mux.HandleFunc("/repos/", s.handlePullByNumber)
+	s.mux.HandleFunc("/stats", s.handleStats)
+	if args.Webhook != nil {
+		s.mux.Handle("/webhook", args.Webhook)
+	}
+
+	return s, nil
+}
+
+// Handle registers an additional handler on the server, for use by the other
+// long running modes
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves until ctx is done, then shuts down gracefully, giving
+// in flight requests time to complete
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:    s.addr,
+		Handler: s,
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		s.logger.Infof("listening on %s", s.addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	return srv.Shutdown(shutdownCtx)
+}
+
+func (s *Server) handlePulls(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	filter, err := parseFilter(s.db, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	prs, err := s.db.GetFilterPullRequests(filter)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	page, perPage, err := parsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	start, end := pageBounds(page, perPage, len(prs))
+	setPageLinks(w, r, page, perPage, len(prs))
+
+	s.writeJSON(w, r, prs[start:end])
+}
+
+// handlePullByID serves /pulls/{id}
+func (s *Server) handlePullByID(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/pulls/"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	pr, ok, err := s.db.GetPullRequestByID(id)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.writeJSON(w, r, pr)
+}
+
+// handlePullByNumber serves /repos/{owner}/{repo}/pulls/{number}
+func (s *Server) handlePullByNumber(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/repos/"), "/")
+	if len(parts) != 4 || parts[2] != "pulls" {
+		http.NotFound(w, r)
+		return
+	}
+
+	number, err := strconv.Atoi(parts[3])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	pr, ok, err := s.db.GetPullRequestByNumber(fmt.Sprintf("%s/%s", parts[0], parts[1]), number)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.writeJSON(w, r, pr)
+}
+
+// handleRepos lists every base repo of the stored pull requests
+func (s *Server) handleRepos(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	prs, err := s.db.GetAllPullRequests()
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	seen := make(map[int]bool)
+	repos := make([]api.RepoData, 0)
+	for _, pr := range prs {
+		if !pr.Base.HasRepo() || seen[pr.Base.Repo.ID] {
+			continue
+		}
+		seen[pr.Base.Repo.ID] = true
+		repos = append(repos, *pr.Base.Repo)
+	}
+
+	page, perPage, err := parsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	start, end := pageBounds(page, perPage, len(repos))
+	setPageLinks(w, r, page, perPage, len(repos))
+
+	s.writeJSON(w, r, repos[start:end])
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	stats, err := computeStats(s.db)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	s.writeJSON(w, r, stats)
+}
+
+// writeJSON writes v with an ETag of its encoding, responding 304 if the
+// client already has it
+func (s *Server) writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:16]))
+
+	w.Header().Set("ETag", etag)
+	if matchETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func matchETag(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) internalError(w http.ResponseWriter, err error) {
+	s.logger.Errorf("serving request - %v", err)
+	http.Error(w, "internal server error", http.StatusInternalServerError)
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+
+	return true
+}
+
+func parsePage(values map[string][]string) (int, int, error) {
+	page, err := parsePositive(values, "page", 1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	perPage, err := parsePositive(values, "per_page", defaultPerPage)
+	if err != nil {
+		return 0, 0, err
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	return page, perPage, nil
+}
+
+func parsePositive(values map[string][]string, key string, def int) (int, error) {
+	raw, ok := values[key]
+	if !ok || len(raw[0]) == 0 {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(raw[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+
+	return n, nil
+}
+
+func pageBounds(page, perPage, total int) (int, int) {
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+
+	return start, end
+}
+
+// setPageLinks sets the X-Total-Count header and a Link header in the same
+// format github uses
+func setPageLinks(w http.ResponseWriter, r *http.Request, page, perPage, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	last := (total + perPage - 1) / perPage
+	if last < 1 {
+		last = 1
+	}
+
+	link := func(p int, rel string) string {
+		u := *r.URL
+		q := u.Query()
+		q.Set("page", strconv.Itoa(p))
+		q.Set("per_page", strconv.Itoa(perPage))
+		u.RawQuery = q.Encode()
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+	}
+
+	links := new(bytes.Buffer)
+	add := func(s string) {
+		if links.Len() != 0 {
+			links.WriteString(", ")
+		}
+		links.WriteString(s)
+	}
+	if page < last {
+		add(link(page+1, "next"))
+		add(link(last, "last"))
+	}
+	if page > 1 {
+		add(link(1, "first"))
+		add(link(page-1, "prev"))
+	}
+
+	if links.Len() != 0 {
+		w.Header().Set("Link", links.String())
+	}
+}
+
+func argMissingError(field string) error {
+	return fmt.Errorf("%s must be set in server Args", field)
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T) (*Server, db.DB) {
+	d, err := db.NewDB(&db.Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	hello := &api.RepoData{ID: 1, Name: "Hello-World", FullName: "octocat/Hello-World", Owner: api.UserData{Login: "octocat"}}
+	spoon := &api.RepoData{ID: 2, Name: "Spoon-Knife", FullName: "octocat/Spoon-Knife", Owner: api.UserData{Login: "octocat"}}
+	merged := time.Date(2011, 1, 26, 19, 1, 12, 0, time.UTC)
+
+	prs := make([]api.PullRequestData, 0)
+	for n := 1; n <= 5; n++ {
+		prs = append(prs, api.PullRequestData{
+			ID: n, Number: n, State: "open", User: api.UserData{Login: "octocat"},
+			Base: api.CommitData{Repo: hello},
+		})
+	}
+	prs = append(prs,
+		api.PullRequestData{ID: 6, Number: 1, State: "closed", MergedAt: &merged, ClosedAt: &merged,
+			User: api.UserData{Login: "hubot"}, Base: api.CommitData{Repo: spoon}},
+		api.PullRequestData{ID: 7, Number: 2, State: "closed", ClosedAt: &merged,
+			User: api.UserData{Login: "hubot"}, Base: api.CommitData{Repo: spoon}},
+	)
+	assert.NoError(t, d.StorePullRequestBatch(prs))
+	assert.NoError(t, d.StoreCIState(1, api.CIStateFailure))
+	assert.NoError(t, d.StoreCIState(2, api.CIStateSuccess))
+
+	s, err := NewServer(&Args{Addr: "127.0.0.1:0", DB: d, Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	return s, d
+}
+
+func get(s http.Handler, path string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	return w
+}
+
+func decodePRs(t *testing.T, w *httptest.ResponseRecorder) []api.PullRequestData {
+	prs := make([]api.PullRequestData, 0)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&prs))
+
+	return prs
+}
+
+func TestListPulls(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	w := get(s, "/pulls")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "7", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, 7, len(decodePRs(t, w)))
+
+	w = get(s, "/pulls?state=open&ci=failure")
+	prs := decodePRs(t, w)
+	assert.Equal(t, 1, len(prs), "Should only list open PRs with failing CI")
+	assert.Equal(t, 1, prs[0].ID)
+
+	w = get(s, "/pulls?repo=octocat/Spoon-Knife&merged=false")
+	prs = decodePRs(t, w)
+	assert.Equal(t, 1, len(prs))
+	assert.Equal(t, 7, prs[0].ID)
+
+	w = get(s, "/pulls?author=octocat&per_page=2&page=2")
+	assert.Equal(t, "5", w.Header().Get("X-Total-Count"))
+	assert.Contains(t, w.Header().Get("Link"), `page=3&per_page=2>; rel="next"`)
+	assert.Contains(t, w.Header().Get("Link"), `page=1&per_page=2>; rel="first"`)
+	prs = decodePRs(t, w)
+	assert.Equal(t, []int{3, 4}, []int{prs[0].ID, prs[1].ID})
+
+	w = get(s, "/pulls?page=9")
+	assert.Equal(t, 0, len(decodePRs(t, w)), "Pages past the end should be empty")
+
+	for _, bad := range []string{"/pulls?state=merged", "/pulls?merged=maybe", "/pulls?ci=red", "/pulls?page=0"} {
+		assert.Equal(t, http.StatusBadRequest, get(s, bad).Code, bad)
+	}
+}
+
+func TestGetPull(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	w := get(s, "/pulls/6")
+	assert.Equal(t, http.StatusOK, w.Code)
+	pr := api.PullRequestData{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&pr))
+	assert.Equal(t, 6, pr.ID)
+
+	w = get(s, "/repos/octocat/Spoon-Knife/pulls/2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&pr))
+	assert.Equal(t, 7, pr.ID)
+
+	assert.Equal(t, http.StatusNotFound, get(s, "/pulls/99").Code)
+	assert.Equal(t, http.StatusNotFound, get(s, "/pulls/abc").Code)
+	assert.Equal(t, http.StatusNotFound, get(s, "/repos/octocat/Spoon-Knife/pulls/99").Code)
+	assert.Equal(t, http.StatusNotFound, get(s, "/repos/octocat/Spoon-Knife/issues/2").Code)
+}
+
+func TestReposAndStats(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	w := get(s, "/repos")
+	repos := make([]api.RepoData, 0)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&repos))
+	assert.Equal(t, 2, len(repos))
+
+	w = get(s, "/stats")
+	stats := Stats{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&stats))
+	assert.Equal(t, 7, stats.Total)
+	assert.Equal(t, 5, stats.Open)
+	assert.Equal(t, 1, stats.Merged)
+	assert.Equal(t, 1, stats.Closed)
+	assert.Equal(t, map[string]int{"octocat/Hello-World": 5, "octocat/Spoon-Knife": 2}, stats.ByRepo)
+	assert.Equal(t, map[string]int{"failure": 1, "success": 1, "none": 3}, stats.ByCIState)
+}
+
+func TestETag(t *testing.T) {
+	s, d := newTestServer(t)
+
+	w := get(s, "/pulls/1")
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	w = get(s, "/pulls/1", "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, 0, w.Body.Len())
+
+	pr, _, _ := d.GetPullRequestByID(1)
+	pr.Title = "changed"
+	assert.NoError(t, d.StorePullRequest(pr))
+
+	w = get(s, "/pulls/1", "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, w.Code, "Changed PR should have a new ETag")
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func TestListenAndServeShutdown(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	// find a free port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s.addr = l.Addr().String()
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.ListenAndServe(ctx)
+	}()
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get(fmt.Sprintf("http://%s/stats", s.addr)); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	assert.NoError(t, err, "Server should come up")
+	resp.Body.Close()
+
+	cancel()
+	select {
+	case err := <-errc:
+		assert.NoError(t, err, "Shutdown should be graceful")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not shut down")
+	}
+}
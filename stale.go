@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/doodles526/gogitpr/config"
+	"github.com/doodles526/gogitpr/stale"
+	"github.com/pkg/errors"
+)
+
+// runStale syncs pull requests along with whatever the stale rules need, then
+// reports the stale ones to every configured notifier
+func runStale(s *syncer) error {
+	rules := staleConfig(&s.cfg.Stale)
+
+	notifiers, err := staleNotifiers(s.cfg)
+	if err != nil {
+		return err
+	}
+
+	staleCfg := *s.cfg
+	staleCfg.FetchReviews = true
+	if rules.NeedsMergeability() {
+		staleCfg.FetchSizes = true
+	}
+
+	staleSyncer := *s
+	staleSyncer.cfg = &staleCfg
+	if err := staleSyncer.syncAll(); err != nil {
+		return err
+	}
+
+	findings, err := stale.Evaluate(s.db, rules, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "evaluating stale rules")
+	}
+	reports := stale.GroupByOwner(findings)
+
+	for _, n := range notifiers {
+		if err := n.Notify(reports); err != nil {
+			return errors.Wrap(err, "notifying of stale PRs")
+		}
+	}
+
+	return nil
+}
+
+func staleConfig(cfg *config.StaleConfig) *stale.Config {
+	rules := &stale.Config{
+		Default: stale.Rules(cfg.Default),
+		Repos:   make(map[string]stale.Rules),
+	}
+	for repo, r := range cfg.Repos {
+		rules.Repos[repo] = stale.Rules(r)
+	}
+
+	return rules
+}
+
+// staleNotifiers returns the configured notifiers, defaulting to stdout
+func staleNotifiers(cfg *config.Config) ([]stale.Notifier, error) {
+	if len(cfg.Stale.Notifiers) == 0 {
+		return []stale.Notifier{stale.NewWriterNotifier(os.Stdout)}, nil
+	}
+
+	notifiers := make([]stale.Notifier, 0, len(cfg.Stale.Notifiers))
+	for _, n := range cfg.Stale.Notifiers {
+		var notifier stale.Notifier
+		var err error
+
+		switch n.Type {
+		case "stdout":
+			notifier = stale.NewWriterNotifier(os.Stdout)
+		case "webhook":
+			notifier, err = stale.NewWebhookNotifier(&stale.WebhookArgs{
+				URL:    n.URL,
+				Logger: cfg.Logger,
+			})
+		case "smtp":
+			notifier, err = stale.NewSMTPNotifier(&stale.SMTPArgs{
+				Addr:     n.Addr,
+				From:     n.From,
+				To:       n.To,
+				Username: n.Username,
+				Password: n.Password,
+				Subject:  n.Subject,
+				Logger:   cfg.Logger,
+			})
+		default:
+			err = fmt.Errorf("unknown notifier type %q", n.Type)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "creating stale notifier")
+		}
+
+		notifiers = append(notifiers, notifier)
+	}
+
+	return notifiers, nil
+}
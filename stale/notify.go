@@ -0,0 +1,213 @@
+package stale
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Notifier tells people about stale pull requests
+type Notifier interface {
+	Notify(reports []OwnerReport) error
+}
+
+// NewWriterNotifier returns a Notifier writing reports to w as a table, e.g.
+// to os.Stdout
+func NewWriterNotifier(w io.Writer) Notifier {
+	return &writerNotifier{w: w}
+}
+
+type writerNotifier struct {
+	w io.Writer
+}
+
+func (n *writerNotifier) Notify(reports []OwnerReport) error {
+	return Write(n.w, reports)
+}
+
+// WebhookArgs configures a webhook Notifier
+type WebhookArgs struct {
+	URL string
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+	Logger *logrus.Logger
+}
+
+func (a *WebhookArgs) validate() error {
+	if len(a.URL) == 0 {
+		return argMissingError("URL")
+	}
+
+	if a.Logger == nil {
+		return argMissingError("Logger")
+	}
+
+	if a.Client == nil {
+		a.Client = http.DefaultClient
+	}
+
+	return nil
+}
+
+// WebhookPayload is the JSON body POSTed by a webhook Notifier
+type WebhookPayload struct {
+	Total  int           `json:"total"`
+	Owners []OwnerReport `json:"owners"`
+}
+
+// NewWebhookNotifier returns a Notifier which POSTs reports as a
+// WebhookPayload. Nothing is sent when there are no reports
+func NewWebhookNotifier(args *WebhookArgs) (Notifier, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	return &webhookNotifier{
+		url:    args.URL,
+		client: args.Client,
+		logger: args.Logger.WithFields(logrus.Fields{"prefix": "StaleWebhook"}),
+	}, nil
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+	logger *logrus.Entry
+}
+
+func (n *webhookNotifier) Notify(reports []OwnerReport) error {
+	if len(reports) == 0 {
+		return nil
+	}
+
+	payload := WebhookPayload{Owners: reports}
+	for _, r := range reports {
+		payload.Total += len(r.Findings)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	n.logger.Debugf("posting %d stale PRs to %s", payload.Total, n.url)
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded %s", n.url, resp.Status)
+	}
+
+	return nil
+}
+
+// SMTPArgs configures an SMTP Notifier
+type SMTPArgs struct {
+	// Addr is the host:port of the mail server
+	Addr string
+	From string
+	To   []string
+	// Username and Password, if set, authenticate with PLAIN auth, which
+	// net/smtp only allows over TLS or to localhost
+	Username string
+	Password string
+	Subject  string
+	Logger   *logrus.Logger
+}
+
+func (a *SMTPArgs) validate() error {
+	if len(a.Addr) == 0 {
+		return argMissingError("Addr")
+	}
+
+	if len(a.From) == 0 {
+		return argMissingError("From")
+	}
+
+	if len(a.To) == 0 {
+		return argMissingError("To")
+	}
+
+	if a.Logger == nil {
+		return argMissingError("Logger")
+	}
+
+	if len(a.Subject) == 0 {
+		a.Subject = "Stale pull requests"
+	}
+
+	return nil
+}
+
+// NewSMTPNotifier returns a Notifier mailing reports as a table. Nothing is
+// sent when there are no reports
+func NewSMTPNotifier(args *SMTPArgs) (Notifier, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	n := &smtpNotifier{
+		addr:    args.Addr,
+		from:    args.From,
+		to:      args.To,
+		subject: args.Subject,
+		logger:  args.Logger.WithFields(logrus.Fields{"prefix": "StaleSMTP"}),
+	}
+
+	if len(args.Username) != 0 {
+		host, _, err := net.SplitHostPort(args.Addr)
+		if err != nil {
+			return nil, err
+		}
+		n.auth = smtp.PlainAuth("", args.Username, args.Password, host)
+	}
+
+	return n, nil
+}
+
+type smtpNotifier struct {
+	addr    string
+	from    string
+	to      []string
+	subject string
+	auth    smtp.Auth
+	logger  *logrus.Entry
+}
+
+func (n *smtpNotifier) Notify(reports []OwnerReport) error {
+	if len(reports) == 0 {
+		return nil
+	}
+
+	msg := new(bytes.Buffer)
+	fmt.Fprintf(msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", n.subject)
+	fmt.Fprint(msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	table := new(bytes.Buffer)
+	if err := Write(table, reports); err != nil {
+		return err
+	}
+	// SMTP needs CRLF line endings
+	msg.WriteString(strings.Replace(table.String(), "\n", "\r\n", -1))
+
+	n.logger.Debugf("mailing stale PRs to %s", strings.Join(n.to, ", "))
+
+	return smtp.SendMail(n.addr, n.auth, n.from, n.to, msg.Bytes())
+}
+
+func argMissingError(field string) error {
+	return fmt.Errorf("%s must be set in stale Args", field)
+}
@@ -0,0 +1,137 @@
+package stale
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+var testReports = []OwnerReport{
+	{Owner: "hubot", Findings: []Finding{
+		{Owner: "hubot", Repo: "octocat/Hello-World", Number: 2, Title: "new-feature",
+			Reasons: []Reason{{Rule: RuleInactive, Message: "not updated for 20 days"}}},
+	}},
+}
+
+func TestWriterNotifier(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, NewWriterNotifier(buf).Notify(testReports))
+	assert.Contains(t, buf.String(), "not updated for 20 days")
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	payloads := make([]WebhookPayload, 0)
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var payload WebhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads = append(payloads, payload)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	_, err := NewWebhookNotifier(&WebhookArgs{Logger: logrus.New()})
+	assert.EqualError(t, err, "URL must be set in stale Args")
+
+	n, err := NewWebhookNotifier(&WebhookArgs{URL: server.URL, Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	assert.NoError(t, n.Notify(testReports))
+	assert.NoError(t, n.Notify(nil))
+	assert.Equal(t, 1, len(payloads), "Nothing should be posted without reports")
+	assert.Equal(t, 1, payloads[0].Total)
+	assert.Equal(t, "hubot", payloads[0].Owners[0].Owner)
+	assert.Equal(t, 2, payloads[0].Owners[0].Findings[0].Number)
+
+	status = http.StatusBadGateway
+	assert.Error(t, n.Notify(testReports), "Non 2xx responses should fail")
+}
+
+// fakeSMTP is a stand-in mail server accepting a single message, which is
+// sent on the returned channel
+func fakeSMTP(t *testing.T) (string, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	messages := make(chan string, 1)
+	go func() {
+		defer l.Close()
+
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) {
+			conn.Write([]byte(line + "\r\n"))
+		}
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				data := new(bytes.Buffer)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				messages <- data.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	return l.Addr().String(), messages
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, messages := fakeSMTP(t)
+
+	_, err := NewSMTPNotifier(&SMTPArgs{Addr: addr, From: "gogitpr@example.com", Logger: logrus.New()})
+	assert.EqualError(t, err, "To must be set in stale Args")
+
+	n, err := NewSMTPNotifier(&SMTPArgs{
+		Addr:   addr,
+		From:   "gogitpr@example.com",
+		To:     []string{"hubot@example.com", "octocat@example.com"},
+		Logger: logrus.New(),
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, n.Notify(nil), "Nothing should be sent without reports")
+	assert.NoError(t, n.Notify(testReports))
+
+	msg := <-messages
+	assert.Contains(t, msg, "To: hubot@example.com, octocat@example.com\r\n")
+	assert.Contains(t, msg, "Subject: Stale pull requests\r\n")
+	assert.Contains(t, msg, "not updated for 20 days")
+}
@@ -0,0 +1,63 @@
+package stale
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// OwnerReport is the stale pull requests an owner should act on
+type OwnerReport struct {
+	Owner    string    `json:"owner"`
+	Findings []Finding `json:"findings"`
+}
+
+// GroupByOwner groups findings by owner, sorting owners by name and their
+// findings by repo and number
+func GroupByOwner(findings []Finding) []OwnerReport {
+	byOwner := make(map[string][]Finding)
+	for _, f := range findings {
+		byOwner[f.Owner] = append(byOwner[f.Owner], f)
+	}
+
+	reports := make([]OwnerReport, 0, len(byOwner))
+	for owner, owned := range byOwner {
+		sort.Slice(owned, func(a, b int) bool {
+			if owned[a].Repo != owned[b].Repo {
+				return owned[a].Repo < owned[b].Repo
+			}
+			return owned[a].Number < owned[b].Number
+		})
+		reports = append(reports, OwnerReport{Owner: owner, Findings: owned})
+	}
+	sort.Slice(reports, func(a, b int) bool {
+		return reports[a].Owner < reports[b].Owner
+	})
+
+	return reports
+}
+
+// Write writes reports to w as an aligned table
+func Write(w io.Writer, reports []OwnerReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "OWNER\tREPO\tNUMBER\tREASONS\tTITLE")
+	for _, r := range reports {
+		for _, f := range r.Findings {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", r.Owner, f.Repo, f.Number, reasons(f), f.Title)
+		}
+	}
+
+	return tw.Flush()
+}
+
+func reasons(f Finding) string {
+	messages := make([]string, 0, len(f.Reasons))
+	for _, r := range f.Reasons {
+		messages = append(messages, r.Message)
+	}
+
+	return strings.Join(messages, ", ")
+}
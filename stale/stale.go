@@ -0,0 +1,200 @@
+package stale
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+)
+
+// Rules decide which open pull requests are stale. A zero field disables its
+// rule
+type Rules struct {
+	// InactiveDays flags pull requests not updated for this many days
+	InactiveDays int
+	// MissingReviewers flags ready pull requests nobody has been asked to
+	// review, and nobody has reviewed
+	MissingReviewers bool
+	// DraftDays flags drafts opened more than this many days ago
+	DraftDays int
+	// MergeConflicts flags pull requests which conflict with their base.
+	// Github only reports conflicts on pull requests fetched on their own
+	MergeConflicts bool
+}
+
+// Config holds the Rules applied to each repo
+type Config struct {
+	// Default applies to repos without their own Rules
+	Default Rules
+	// Repos are keyed by owner/name, case insensitively, and replace Default
+	// entirely rather than being merged with it
+	Repos map[string]Rules
+}
+
+// RulesFor returns the Rules applied to the repo named by fullName
+func (c *Config) RulesFor(fullName string) Rules {
+	for name, rules := range c.Repos {
+		if strings.EqualFold(name, fullName) {
+			return rules
+		}
+	}
+
+	return c.Default
+}
+
+// NeedsMergeability reports whether any rule needs pull requests to be fetched
+// on their own, as only then does github report merge conflicts
+func (c *Config) NeedsMergeability() bool {
+	if c.Default.MergeConflicts {
+		return true
+	}
+
+	for _, rules := range c.Repos {
+		if rules.MergeConflicts {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Rule names a rule which flagged a pull request
+type Rule string
+
+const (
+	// RuleInactive is Rules.InactiveDays
+	RuleInactive Rule = "inactive"
+	// RuleMissingReviewers is Rules.MissingReviewers
+	RuleMissingReviewers Rule = "missing-reviewers"
+	// RuleDraft is Rules.DraftDays
+	RuleDraft Rule = "draft"
+	// RuleMergeConflicts is Rules.MergeConflicts
+	RuleMergeConflicts Rule = "merge-conflicts"
+)
+
+// Reason is why a rule flagged a pull request
+type Reason struct {
+	Rule    Rule   `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Finding is a stale pull request, and every reason it was flagged
+type Finding struct {
+	PullRequest api.PullRequestData `json:"-"`
+	// Owner is who should act on the pull request: its assignee, otherwise
+	// its author
+	Owner   string   `json:"owner"`
+	Repo    string   `json:"repo"`
+	Number  int      `json:"number"`
+	Title   string   `json:"title"`
+	URL     string   `json:"url"`
+	Reasons []Reason `json:"reasons"`
+}
+
+// Evaluate applies the rules of cfg to every open pull request stored in d,
+// returning those flagged by at least one rule
+func Evaluate(d db.DB, cfg *Config, now time.Time) ([]Finding, error) {
+	prs, err := d.GetFilterPullRequests(db.FilterState("open"))
+	if err != nil {
+		return nil, err
+	}
+
+	findings := make([]Finding, 0)
+	for _, pr := range prs {
+		repo := ""
+		if pr.Base.HasRepo() {
+			repo = pr.Base.Repo.FullName
+		}
+
+		reasons, err := evaluate(d, cfg.RulesFor(repo), pr, now)
+		if err != nil {
+			return nil, err
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		owner := pr.AssigneeLogin()
+		if len(owner) == 0 {
+			owner = pr.User.Login
+		}
+
+		findings = append(findings, Finding{
+			PullRequest: pr,
+			Owner:       owner,
+			Repo:        repo,
+			Number:      pr.Number,
+			Title:       pr.Title,
+			URL:         pr.HTMLURL,
+			Reasons:     reasons,
+		})
+	}
+
+	return findings, nil
+}
+
+func evaluate(d db.DB, rules Rules, pr api.PullRequestData, now time.Time) ([]Reason, error) {
+	reasons := make([]Reason, 0)
+
+	if rules.InactiveDays > 0 {
+		if idle := days(now.Sub(pr.UpdatedAt)); idle >= rules.InactiveDays {
+			reasons = append(reasons, Reason{
+				Rule:    RuleInactive,
+				Message: fmt.Sprintf("not updated for %d days", idle),
+			})
+		}
+	}
+
+	if rules.MissingReviewers && !pr.Draft && !pr.HasRequestedReviewers() {
+		reviewed, err := hasReview(d, pr)
+		if err != nil {
+			return nil, err
+		}
+		if !reviewed {
+			reasons = append(reasons, Reason{
+				Rule:    RuleMissingReviewers,
+				Message: "no reviewers requested",
+			})
+		}
+	}
+
+	if rules.DraftDays > 0 && pr.Draft {
+		if age := days(now.Sub(pr.CreatedAt)); age >= rules.DraftDays {
+			reasons = append(reasons, Reason{
+				Rule:    RuleDraft,
+				Message: fmt.Sprintf("draft for %d days", age),
+			})
+		}
+	}
+
+	if rules.MergeConflicts && pr.HasConflicts() {
+		reasons = append(reasons, Reason{
+			Rule:    RuleMergeConflicts,
+			Message: "conflicts with its base branch",
+		})
+	}
+
+	return reasons, nil
+}
+
+// hasReview reports whether anyone but the author has reviewed pr
+func hasReview(d db.DB, pr api.PullRequestData) (bool, error) {
+	reviews, err := d.GetReviews(pr.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, review := range reviews {
+		if review.User.Login != pr.User.Login {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func days(d time.Duration) int {
+	return int(d / (24 * time.Hour))
+}
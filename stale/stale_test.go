@@ -0,0 +1,105 @@
+package stale
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func daysAgo(n int) time.Time {
+	return now.Add(-time.Duration(n) * 24 * time.Hour)
+}
+
+func newTestDB(t *testing.T) db.DB {
+	d, err := db.NewDB(&db.Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	hello := &api.RepoData{FullName: "octocat/Hello-World"}
+	spoon := &api.RepoData{FullName: "octocat/Spoon-Knife"}
+	octocat := api.UserData{Login: "octocat"}
+	hubot := api.UserData{Login: "hubot"}
+	conflicted := false
+
+	assert.NoError(t, d.StorePullRequestBatch([]api.PullRequestData{
+		// fresh and reviewed
+		{ID: 1, Number: 1, State: "open", User: octocat, Base: api.CommitData{Repo: hello},
+			CreatedAt: daysAgo(1), UpdatedAt: daysAgo(1), RequestedReviewers: []api.UserData{hubot}},
+		// idle for 20 days, unreviewed, assigned to hubot
+		{ID: 2, Number: 2, State: "open", User: octocat, Assignee: &hubot, Base: api.CommitData{Repo: hello},
+			CreatedAt: daysAgo(30), UpdatedAt: daysAgo(20)},
+		// a long running draft with conflicts
+		{ID: 3, Number: 3, State: "open", User: octocat, Draft: true, Base: api.CommitData{Repo: hello},
+			CreatedAt: daysAgo(60), UpdatedAt: daysAgo(1), Mergeable: &conflicted, MergeableState: "dirty"},
+		// idle for 10 days in a repo with stricter rules
+		{ID: 4, Number: 1, State: "open", User: hubot, Base: api.CommitData{Repo: spoon},
+			CreatedAt: daysAgo(10), UpdatedAt: daysAgo(10), RequestedReviewers: []api.UserData{octocat}},
+		// closed PRs are never stale
+		{ID: 5, Number: 2, State: "closed", User: hubot, Base: api.CommitData{Repo: spoon},
+			CreatedAt: daysAgo(100), UpdatedAt: daysAgo(100)},
+		// unrequested, but reviewed by someone else
+		{ID: 6, Number: 6, State: "open", User: octocat, Base: api.CommitData{Repo: hello},
+			CreatedAt: daysAgo(1), UpdatedAt: daysAgo(1)},
+	}))
+	assert.NoError(t, d.StoreReview(6, api.ReviewData{ID: 1, User: hubot, State: "COMMENTED"}))
+
+	return d
+}
+
+func TestEvaluate(t *testing.T) {
+	d := newTestDB(t)
+
+	cfg := &Config{
+		Default: Rules{InactiveDays: 14, MissingReviewers: true, DraftDays: 30, MergeConflicts: true},
+		Repos: map[string]Rules{
+			// viper lower cases keys
+			"octocat/spoon-knife": {InactiveDays: 7},
+		},
+	}
+	assert.True(t, cfg.NeedsMergeability())
+
+	findings, err := Evaluate(d, cfg, now)
+	assert.NoError(t, err, "Should evaluate rules")
+
+	reports := GroupByOwner(findings)
+	assert.Equal(t, 2, len(reports))
+
+	assert.Equal(t, "hubot", reports[0].Owner, "Assignees should own PRs over their authors")
+	assert.Equal(t, 2, len(reports[0].Findings))
+	assert.Equal(t, "octocat/Hello-World", reports[0].Findings[0].Repo)
+	assert.Equal(t, []Reason{
+		{Rule: RuleInactive, Message: "not updated for 20 days"},
+		{Rule: RuleMissingReviewers, Message: "no reviewers requested"},
+	}, reports[0].Findings[0].Reasons)
+	assert.Equal(t, "octocat/Spoon-Knife", reports[0].Findings[1].Repo)
+	assert.Equal(t, []Reason{{Rule: RuleInactive, Message: "not updated for 10 days"}}, reports[0].Findings[1].Reasons)
+
+	assert.Equal(t, "octocat", reports[1].Owner)
+	assert.Equal(t, 1, len(reports[1].Findings))
+	assert.Equal(t, 3, reports[1].Findings[0].Number)
+	assert.Equal(t, []Reason{
+		{Rule: RuleDraft, Message: "draft for 60 days"},
+		{Rule: RuleMergeConflicts, Message: "conflicts with its base branch"},
+	}, reports[1].Findings[0].Reasons, "Drafts don't need reviewers yet")
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, Write(buf, reports))
+	assert.Contains(t, buf.String(), "draft for 60 days, conflicts with its base branch")
+}
+
+func TestEvaluateDisabledRules(t *testing.T) {
+	d := newTestDB(t)
+
+	cfg := &Config{}
+	assert.False(t, cfg.NeedsMergeability())
+
+	findings, err := Evaluate(d, cfg, now)
+	assert.NoError(t, err)
+	assert.Empty(t, findings, "Zero rules should flag nothing")
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+
+	"github.com/doodles526/gogitpr/metrics"
+	"github.com/pkg/errors"
+)
+
+// runStats syncs pull requests along with their reviews and sizes, then prints
+// metrics computed from them
+func runStats(s *syncer) error {
+	statsCfg := *s.cfg
+	if len(statsCfg.PRState) == 0 {
+		// lead times and merge rates are measured on closed PRs
+		statsCfg.PRState = "all"
+	}
+	statsCfg.FetchReviews = true
+	statsCfg.FetchSizes = true
+
+	statsSyncer := *s
+	statsSyncer.cfg = &statsCfg
+	if err := statsSyncer.syncAll(); err != nil {
+		return err
+	}
+
+	report, err := metrics.Compute(s.db, nil)
+	if err != nil {
+		return errors.Wrap(err, "computing metrics")
+	}
+
+	if err := metrics.Write(os.Stdout, report); err != nil {
+		return errors.Wrap(err, "writing metrics")
+	}
+
+	return nil
+}
@@ -0,0 +1,508 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/codeowners"
+	"github.com/doodles526/gogitpr/config"
+	"github.com/doodles526/gogitpr/db"
+	"github.com/doodles526/gogitpr/diff"
+	"github.com/doodles526/gogitpr/report"
+	"github.com/doodles526/gogitpr/scheduler"
+	"github.com/doodles526/gogitpr/telemetry"
+	"github.com/pkg/errors"
+)
+
+// runSync fetches pull requests once, printing whichever reports are
+// configured
+func runSync(s *syncer) error {
+	if err := s.syncAll(); err != nil {
+		return err
+	}
+
+	if s.cfg.PRReport {
+		reports, err := report.PullRequests(s.db, nil)
+		if err != nil {
+			return errors.Wrap(err, "reporting PRs")
+		}
+
+		if err := report.WritePullRequests(os.Stdout, reports); err != nil {
+			return errors.Wrap(err, "reporting PRs")
+		}
+	}
+
+	if s.cfg.OwnersReport {
+		reports, err := report.OwnerReviews(s.db)
+		if err != nil {
+			return errors.Wrap(err, "reporting owner reviews")
+		}
+
+		if err := report.WriteOwnerReviews(os.Stdout, reports); err != nil {
+			return errors.Wrap(err, "reporting owner reviews")
+		}
+	}
+
+	if s.cfg.MilestoneReport {
+		reports, err := report.Milestones(s.db, time.Now())
+		if err != nil {
+			return errors.Wrap(err, "reporting milestones")
+		}
+
+		if err := report.WriteMilestones(os.Stdout, reports); err != nil {
+			return errors.Wrap(err, "reporting milestones")
+		}
+	}
+
+	allPRs, err := s.db.GetAllPullRequests()
+	if err != nil {
+		return errors.Wrap(err, "fetching PRs")
+	}
+
+	if s.cfg.PrintResult {
+		fmt.Println(allPRs)
+	}
+
+	return nil
+}
+
+// syncer fetches pull requests and their related data into a DB
+type syncer struct {
+	cfg *config.Config
+	gh  api.GithubAPI
+	db  db.DB
+
+	metrics *syncMetrics
+}
+
+func newSyncer(cfg *config.Config, gh api.GithubAPI, prDB db.DB, reg *telemetry.Registry) *syncer {
+	return &syncer{
+		cfg:     cfg,
+		gh:      gh,
+		db:      prDB,
+		metrics: newSyncMetrics(reg),
+	}
+}
+
+// syncAll fetches every pull request of the configured user or org, along
+// with whichever related data is configured, unless syncing is skipped
+func (s *syncer) syncAll() error {
+	if s.cfg.SkipSync {
+		s.cfg.Logger.Info("skipping sync")
+		return nil
+	}
+
+	return s.syncTarget(context.Background(), defaultTarget(s.cfg))
+}
+
+// defaultTarget is the target of the configured user or org
+func defaultTarget(cfg *config.Config) scheduler.Target {
+	return scheduler.Target{
+		Name:   cfg.GithubUser + cfg.GithubOrg,
+		User:   cfg.GithubUser,
+		Org:    cfg.GithubOrg,
+		Search: cfg.Search,
+	}
+}
+
+// syncTarget fetches every pull request of target, along with whichever
+// related data is configured, recording how it went. Its requests are
+// cancelled along with ctx
+func (s *syncer) syncTarget(ctx context.Context, target scheduler.Target) error {
+	start := time.Now()
+	prs, err := s.fetchTarget(ctx, target)
+	s.metrics.observe(target, start, prs, err)
+
+	return err
+}
+
+// fetchTarget does the work of syncTarget, returning the PRs fetched even if
+// storing their related data failed
+func (s *syncer) fetchTarget(ctx context.Context, target scheduler.Target) ([]api.PullRequestData, error) {
+	cfg, gh, prDB := s.cfg, s.gh.WithContext(ctx), s.db
+
+	prArgs := &api.PullRequestArgs{
+		User:  target.User,
+		Org:   target.Org,
+		State: cfg.PRState,
+		RepoFilter: &api.RepoArgs{
+			Type:         cfg.RepoType,
+			Sort:         cfg.RepoSort,
+			SkipArchived: cfg.SkipArchived,
+			SkipForks:    cfg.SkipForks,
+			Topics:       cfg.RepoTopics,
+			Include:      cfg.RepoInclude,
+			Exclude:      cfg.RepoExclude,
+		},
+	}
+
+	if len(target.Search) == 0 {
+		// fetch the repos here rather than leave it to PullRequest().Get, so
+		// they are stored too
+		repos, err := s.syncRepos(gh, target, prArgs.RepoFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, repo := range repos {
+			prArgs.Repos = append(prArgs.Repos, repo.Name)
+		}
+	}
+
+	prs, err := fetchPullRequests(gh, target, prArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting Pull Requests")
+	}
+
+	if cfg.FetchSizes {
+		if prs, err = fetchEach(gh, prs); err != nil {
+			return nil, errors.Wrap(err, "getting PR sizes")
+		}
+	}
+
+	if err := prDB.StorePullRequestBatch(prs); err != nil {
+		return prs, errors.Wrap(err, "storing PRs")
+	}
+
+	for _, pr := range prs {
+		if err := prDB.StoreClosingIssues(pr.ID, pr.ClosingReferences()); err != nil {
+			return prs, errors.Wrap(err, "storing closing issues")
+		}
+	}
+
+	if cfg.FetchCIState {
+		if err := storeCIStates(gh, prDB, prs); err != nil {
+			return prs, errors.Wrap(err, "storing CI states")
+		}
+	}
+
+	if cfg.FetchReviews {
+		if err := storeReviews(gh, prDB, prs); err != nil {
+			return prs, errors.Wrap(err, "storing reviews")
+		}
+	}
+
+	if cfg.FetchCodeOwners {
+		if err := storeRequiredOwners(gh, prDB, prs); err != nil {
+			return prs, errors.Wrap(err, "storing required owners")
+		}
+	}
+
+	if cfg.FetchUsers {
+		if err := storeUsers(gh, prDB, target.Org, prs); err != nil {
+			return prs, errors.Wrap(err, "storing users")
+		}
+	}
+
+	if cfg.MilestoneReport {
+		if err := storeMilestones(gh, prDB, target, prArgs.Repos); err != nil {
+			return prs, errors.Wrap(err, "storing milestones")
+		}
+	}
+
+	return prs, nil
+}
+
+// syncRepos fetches and stores the repos of target with gh, returning those
+// which match filter
+func (s *syncer) syncRepos(gh api.GithubAPI, target scheduler.Target, filter *api.RepoArgs) ([]api.RepoData, error) {
+	// every repo is listed, not just those matching filter, so a repo which
+	// no longer matches, e.g. once archived, isn't taken to be deleted
+	listArgs := &api.RepoArgs{
+		User:      target.User,
+		Org:       target.Org,
+		Type:      filter.Type,
+		Sort:      filter.Sort,
+		Direction: filter.Direction,
+	}
+	listed, err := gh.Repos().Get(listArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting repos")
+	}
+
+	repoArgs := *filter
+	repoArgs.User, repoArgs.Org = target.User, target.Org
+	repos, err := repoArgs.Filter(listed)
+	if err != nil {
+		return nil, errors.Wrap(err, "filtering repos")
+	}
+
+	if err := s.storeRepos(gh, target, listed, repos); err != nil {
+		return nil, errors.Wrap(err, "storing repos")
+	}
+
+	return repos, nil
+}
+
+// storeRepos stores every repo listed for target, along with the languages of
+// those synced if configured. Repos of target stored by an earlier sync but
+// not listed now are marked missing, and renamed repos are logged
+func (s *syncer) storeRepos(gh api.GithubAPI, target scheduler.Target, listed, synced []api.RepoData) error {
+	cfg, prDB := s.cfg, s.db
+	owner := target.User + target.Org
+	syncedAt := time.Now().UTC()
+
+	stored, err := prDB.ListRepos(db.FilterReposOwner(owner))
+	if err != nil {
+		return err
+	}
+	previous := make(map[int]db.Repo, len(stored))
+	for _, r := range stored {
+		previous[r.ID] = r
+	}
+
+	isSynced := make(map[int]bool, len(synced))
+	for _, repo := range synced {
+		isSynced[repo.ID] = true
+	}
+
+	for _, repo := range listed {
+		r := db.Repo{RepoData: repo, SyncedAt: syncedAt}
+
+		// languages are left as stored for repos filtered out
+		if cfg.FetchLanguages && isSynced[repo.ID] {
+			if r.Languages, err = gh.Repos().Languages(repo.Owner.Login, repo.Name); err != nil {
+				return err
+			}
+		}
+
+		if old, ok := previous[repo.ID]; ok && !strings.EqualFold(old.FullName, repo.FullName) {
+			cfg.Logger.Infof("repo %s has been renamed to %s", old.FullName, repo.FullName)
+		}
+		delete(previous, repo.ID)
+
+		if err := prDB.StoreRepo(r); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range previous {
+		if r.Missing {
+			continue
+		}
+
+		cfg.Logger.Warnf("repo %s was not found, it may have been deleted or transferred", r.FullName)
+		r.Missing = true
+		if err := prDB.StoreRepo(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func storeCIStates(gh api.GithubAPI, prDB db.DB, prs []api.PullRequestData) error {
+	for _, pr := range prs {
+		if pr.State != "open" {
+			continue
+		}
+
+		statusArgs, err := api.StatusArgsForPullRequest(pr)
+		if err != nil {
+			return err
+		}
+
+		state, err := gh.Statuses().CIState(statusArgs)
+		if err != nil {
+			return err
+		}
+
+		if err := prDB.StoreCIState(pr.ID, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func storeReviews(gh api.GithubAPI, prDB db.DB, prs []api.PullRequestData) error {
+	for _, pr := range prs {
+		ref, ok := pr.PullRequestRef()
+		if !ok {
+			continue
+		}
+
+		reviews, err := gh.PullRequest().Reviews(&ref)
+		if err != nil {
+			return err
+		}
+
+		for _, review := range reviews {
+			if err := prDB.StoreReview(pr.ID, review); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// storeRequiredOwners evaluates the CODEOWNERS file of the base repo of each
+// open PR against the files it changes, both before and after any rename.
+// Repos without a CODEOWNERS file require no owners
+func storeRequiredOwners(gh api.GithubAPI, prDB db.DB, prs []api.PullRequestData) error {
+	rulesets := make(map[int]*codeowners.Ruleset)
+	for _, pr := range prs {
+		ref, ok := pr.PullRequestRef()
+		if pr.State != "open" || !ok {
+			continue
+		}
+
+		repo := pr.Base.Repo
+		rules, ok := rulesets[repo.ID]
+		if !ok {
+			text, err := gh.Repos().CodeOwners(repo)
+			if err != nil && err != api.ErrNoCodeOwners {
+				return err
+			}
+
+			if rules, err = codeowners.Parse(strings.NewReader(text)); err != nil {
+				return err
+			}
+			rulesets[repo.ID] = rules
+		}
+
+		if len(rules.Rules) == 0 {
+			if err := prDB.StoreRequiredOwners(pr.ID, []string{}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		text, err := gh.PullRequest().Diff(&ref)
+		if err != nil {
+			return err
+		}
+
+		files, err := diff.Parse(strings.NewReader(text))
+		if err != nil {
+			return errors.Wrapf(err, "parsing diff of %s#%d", repo.FullName, pr.Number)
+		}
+
+		paths := make([]string, 0, len(files))
+		for _, f := range files {
+			paths = append(paths, f.Name())
+			if f.Status == diff.Renamed {
+				paths = append(paths, f.OldName)
+			}
+		}
+
+		if err := prDB.StoreRequiredOwners(pr.ID, rules.RequiredOwners(paths)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchEach refetches every PR individually, returning them in the same order
+func fetchEach(gh api.GithubAPI, prs []api.PullRequestData) ([]api.PullRequestData, error) {
+	detailed := make([]api.PullRequestData, 0, len(prs))
+	for _, pr := range prs {
+		ref, ok := pr.PullRequestRef()
+		if !ok {
+			detailed = append(detailed, pr)
+			continue
+		}
+
+		full, err := gh.PullRequest().GetByRef(&ref)
+		if err != nil {
+			return nil, err
+		}
+		detailed = append(detailed, *full)
+	}
+
+	return detailed, nil
+}
+
+func storeMilestones(gh api.GithubAPI, prDB db.DB, target scheduler.Target, repos []string) error {
+	milestones, err := gh.Milestones().Get(&api.MilestoneArgs{
+		User:  target.User,
+		Org:   target.Org,
+		Repos: repos,
+		State: "all",
+	})
+	if err != nil {
+		return err
+	}
+
+	return prDB.StoreMilestoneBatch(milestones)
+}
+
+func storeUsers(gh api.GithubAPI, prDB db.DB, org string, prs []api.PullRequestData) error {
+	seen := make(map[string]bool)
+	for _, pr := range prs {
+		if seen[pr.User.Login] {
+			continue
+		}
+		seen[pr.User.Login] = true
+
+		profile, err := gh.Users().Get(pr.User.Login)
+		if err != nil {
+			return err
+		}
+
+		if err := prDB.StoreUser(*profile); err != nil {
+			return err
+		}
+	}
+
+	if len(org) == 0 {
+		return nil
+	}
+
+	teams, err := gh.Users().OrgTeams(org)
+	if err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		members, err := gh.Users().TeamMembers(org, team.Slug)
+		if err != nil {
+			return err
+		}
+
+		if err := prDB.StoreTeam(team, members); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchPullRequests lists every PR of the target's user or org, or when it has
+// a search only fetches the PRs the search finds
+func fetchPullRequests(gh api.GithubAPI, target scheduler.Target, prArgs *api.PullRequestArgs) ([]api.PullRequestData, error) {
+	if len(target.Search) == 0 {
+		return gh.PullRequest().Get(prArgs)
+	}
+
+	searchArgs := &api.SearchArgs{
+		User:  target.User,
+		Org:   target.Org,
+		Query: target.Search,
+	}
+	if prArgs.State != "all" {
+		// searches find PRs of any state by default
+		searchArgs.State = prArgs.State
+	}
+
+	results, err := gh.Search().PullRequests(searchArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]api.PullRequestRef, 0, len(results))
+	for _, result := range results {
+		if ref, ok := result.PullRequestRef(); ok {
+			refs = append(refs, ref)
+		}
+	}
+
+	// search results are the issue side of each PR, lacking its head and base
+	return gh.PullRequest().GetByRefs(refs)
+}
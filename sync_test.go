@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/config"
+	"github.com/doodles526/gogitpr/db"
+	"github.com/doodles526/gogitpr/githubtest"
+	"github.com/doodles526/gogitpr/scheduler"
+	"github.com/doodles526/gogitpr/telemetry"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncReposArchived(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+
+	server.HandleList("/orgs/octocat/repos",
+		`{"id": 1, "name": "Hello-World", "full_name": "octocat/Hello-World", "owner": {"login": "octocat"}, "archived": true}`,
+		`{"id": 2, "name": "Spoon-Knife", "full_name": "octocat/Spoon-Knife", "owner": {"login": "octocat"}}`,
+	)
+
+	logger := logrus.New()
+	gh, err := api.NewGithubAPI(&api.GithubAPIArgs{
+		BaseURL:         server.URL,
+		ApplicationName: "test",
+		Logger:          logger,
+	})
+	assert.NoError(t, err)
+
+	prDB, err := db.NewDB(&db.Args{Logger: logger})
+	assert.NoError(t, err)
+
+	// all were synced before Hello-World was archived and linguist was
+	// deleted
+	for _, r := range []api.RepoData{
+		{ID: 1, Name: "Hello-World", FullName: "octocat/Hello-World"},
+		{ID: 2, Name: "Spoon-Knife", FullName: "octocat/Spoon-Knife"},
+		{ID: 3, Name: "linguist", FullName: "octocat/linguist"},
+	} {
+		r.Owner.Login = "octocat"
+		assert.NoError(t, prDB.StoreRepo(db.Repo{RepoData: r}))
+	}
+
+	cfg := &config.Config{Logger: logger, SkipArchived: true}
+	s := newSyncer(cfg, gh, prDB, telemetry.NewRegistry())
+
+	target := scheduler.Target{Name: "octocat", Org: "octocat"}
+	repos, err := s.syncRepos(gh, target, &api.RepoArgs{SkipArchived: true})
+	assert.NoError(t, err)
+	if assert.Len(t, repos, 1) {
+		assert.Equal(t, "Spoon-Knife", repos[0].Name, "Archived repo should be filtered out")
+	}
+
+	archived, ok, err := prDB.GetRepo("octocat/Hello-World")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, archived.Archived, "Archived repo should be stored as archived")
+	assert.False(t, archived.Missing, "Repo filtered out shouldn't be marked missing")
+
+	deleted, ok, err := prDB.GetRepo("octocat/linguist")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, deleted.Missing, "Repo no longer listed should be marked missing")
+}
+
+func TestSyncTargetCancelled(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+
+	server.HandleList("/orgs/octocat/repos")
+
+	logger := logrus.New()
+	gh, err := api.NewGithubAPI(&api.GithubAPIArgs{
+		BaseURL:         server.URL,
+		ApplicationName: "test",
+		Logger:          logger,
+	})
+	assert.NoError(t, err)
+
+	prDB, err := db.NewDB(&db.Args{Logger: logger})
+	assert.NoError(t, err)
+
+	s := newSyncer(&config.Config{Logger: logger}, gh, prDB, telemetry.NewRegistry())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = s.syncTarget(ctx, scheduler.Target{Name: "octocat", Org: "octocat"})
+	assert.True(t, errors.Is(err, context.Canceled), "Sync should stop with its context")
+	assert.Equal(t, 0, server.Requests("GET", "/orgs/octocat/repos"), "No requests should be made once cancelled")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/scheduler"
+	"github.com/doodles526/gogitpr/telemetry"
+)
+
+// syncMetrics records the syncs of each target
+type syncMetrics struct {
+	duration    *telemetry.Histogram
+	syncs       *telemetry.Counter
+	errors      *telemetry.Counter
+	lastSuccess *telemetry.Gauge
+	prsFetched  *telemetry.Gauge
+}
+
+func newSyncMetrics(reg *telemetry.Registry) *syncMetrics {
+	if reg == nil {
+		return nil
+	}
+
+	return &syncMetrics{
+		duration: reg.NewHistogram("gogitpr_sync_duration_seconds",
+			"Time taken to sync a target.", []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800}, "target"),
+		syncs: reg.NewCounter("gogitpr_syncs_total",
+			"Syncs run of a target.", "target"),
+		errors: reg.NewCounter("gogitpr_sync_errors_total",
+			"Syncs of a target which failed.", "target"),
+		lastSuccess: reg.NewGauge("gogitpr_sync_last_success_timestamp_seconds",
+			"Unix time a target last synced successfully.", "target"),
+		prsFetched: reg.NewGauge("gogitpr_sync_pull_requests_fetched",
+			"Pull requests fetched from each repo by the last sync of a target.", "target", "repo"),
+	}
+}
+
+// observe records a sync of target which started at start. Safe to call on a
+// nil *syncMetrics
+func (m *syncMetrics) observe(target scheduler.Target, start time.Time, prs []api.PullRequestData, err error) {
+	if m == nil {
+		return
+	}
+
+	m.duration.Observe(time.Since(start).Seconds(), target.Name)
+	m.syncs.Inc(target.Name)
+	if err != nil {
+		m.errors.Inc(target.Name)
+	} else {
+		m.lastSuccess.Set(float64(time.Now().Unix()), target.Name)
+	}
+
+	if prs == nil {
+		// nothing was fetched, so keep reporting the last sync which was
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, pr := range prs {
+		repo := ""
+		if pr.Base.HasRepo() {
+			repo = pr.Base.Repo.FullName
+		}
+		counts[repo]++
+	}
+	for repo, count := range counts {
+		m.prsFetched.Set(float64(count), target.Name, repo)
+	}
+}
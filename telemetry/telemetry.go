@@ -0,0 +1,326 @@
+package telemetry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of the histogram buckets
+// used for latencies when none are given
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// CollectFunc is run before every scrape, to update metrics which are cheaper
+// to compute on demand than to keep up to date
+type CollectFunc func() error
+
+// Registry holds metrics, and writes them in the Prometheus text format
+type Registry struct {
+	mu         sync.Mutex
+	families   map[string]*family
+	collectors []CollectFunc
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+// NewCounter registers a counter. It panics if name is already registered, as
+// that is always a programming error
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(name, help, "counter", nil, labels)}
+}
+
+// NewGauge registers a gauge. It panics if name is already registered
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(name, help, "gauge", nil, labels)}
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds, or
+// DefaultBuckets when nil. It panics if name is already registered
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+
+	return &Histogram{r.register(name, help, "histogram", sorted, labels)}
+}
+
+// OnCollect registers f to be run before every scrape
+func (r *Registry) OnCollect(f CollectFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, f)
+}
+
+func (r *Registry) register(name, help, typ string, buckets []float64, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.families[name]; ok {
+		panic(fmt.Sprintf("telemetry: %s is already registered", name))
+	}
+
+	f := &family{
+		name:    name,
+		help:    help,
+		typ:     typ,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	r.families[name] = f
+
+	return f
+}
+
+// Write runs every CollectFunc, then writes every metric to w in the
+// Prometheus text format, sorted by name and labels
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	collectors := make([]CollectFunc, len(r.collectors))
+	copy(collectors, r.collectors)
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	for _, collect := range collectors {
+		if err := collect(); err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(families, func(a, b int) bool {
+		return families[a].name < families[b].name
+	})
+
+	for _, f := range families {
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handler serves the metrics of r for Prometheus to scrape
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		buf := new(bytes.Buffer)
+		if err := r.Write(buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", ContentType)
+		w.Write(buf.Bytes())
+	})
+}
+
+// Counter is a value which only goes up, partitioned by its labels
+type Counter struct {
+	f *family
+}
+
+// Inc adds 1 to the series with the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series with the given label
+// values
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("telemetry: counter %s can't decrease", c.f.name))
+	}
+
+	c.f.update(labelValues, func(s *series) {
+		s.value += v
+	})
+}
+
+// Gauge is a value which can go up and down, partitioned by its labels
+type Gauge struct {
+	f *family
+}
+
+// Set sets the series with the given label values to v
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.f.update(labelValues, func(s *series) {
+		s.value = v
+	})
+}
+
+// Add adds v to the series with the given label values
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.f.update(labelValues, func(s *series) {
+		s.value += v
+	})
+}
+
+// Reset removes every series, so label values which no longer exist stop
+// being reported
+func (g *Gauge) Reset() {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	g.f.series = make(map[string]*series)
+}
+
+// Histogram counts observations into buckets, partitioned by its labels
+type Histogram struct {
+	f *family
+}
+
+// Observe records v in the series with the given label values
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.f.update(labelValues, func(s *series) {
+		if s.bucketCounts == nil {
+			s.bucketCounts = make([]uint64, len(h.f.buckets))
+		}
+		for idx, bound := range h.f.buckets {
+			if v <= bound {
+				s.bucketCounts[idx]++
+			}
+		}
+		s.count++
+		s.value += v
+	})
+}
+
+type family struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is a single set of label values. value is the sum of observations
+// for histograms
+type series struct {
+	labelValues  []string
+	value        float64
+	count        uint64
+	bucketCounts []uint64
+}
+
+func (f *family) update(labelValues []string, fn func(s *series)) {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("telemetry: %s takes %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		f.series[key] = s
+	}
+	fn(s)
+}
+
+func (f *family) write(w io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	b := new(bytes.Buffer)
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.typ)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.typ != "histogram" {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, f.labelPairs(s, "", 0), formatFloat(s.value))
+			continue
+		}
+
+		for idx, bound := range f.buckets {
+			var count uint64
+			if s.bucketCounts != nil {
+				count = s.bucketCounts[idx]
+			}
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelPairs(s, "le", bound), count)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelPairs(s, "le", math.Inf(1)), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, f.labelPairs(s, "", 0), formatFloat(s.value))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, f.labelPairs(s, "", 0), s.count)
+	}
+
+	_, err := w.Write(b.Bytes())
+
+	return err
+}
+
+// labelPairs formats the labels of s, followed by an extra label when set
+func (f *family) labelPairs(s *series, extra string, extraValue float64) string {
+	pairs := make([]string, 0, len(f.labels)+1)
+	for idx, label := range f.labels {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", label, escapeLabel(s.labelValues[idx])))
+	}
+	if len(extra) != 0 {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", extra, formatFloat(extraValue)))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
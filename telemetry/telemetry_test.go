@@ -0,0 +1,98 @@
+package telemetry
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+
+	requests := r.NewCounter("requests_total", "Requests made.", "method", "status")
+	requests.Inc("GET", "200")
+	requests.Inc("GET", "200")
+	requests.Add(3, "POST", "500")
+
+	remaining := r.NewGauge("rate_limit_remaining", "Requests left.\nPer hour")
+	remaining.Set(4999)
+
+	latency := r.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1}, "path")
+	latency.Observe(0.05, `/a"b`)
+	latency.Observe(0.5, `/a"b`)
+	latency.Observe(5, `/a"b`)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, r.Write(buf))
+	assert.Equal(t, `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{path="/a\"b",le="0.1"} 1
+latency_seconds_bucket{path="/a\"b",le="1"} 2
+latency_seconds_bucket{path="/a\"b",le="+Inf"} 3
+latency_seconds_sum{path="/a\"b"} 5.55
+latency_seconds_count{path="/a\"b"} 3
+# HELP rate_limit_remaining Requests left.\nPer hour
+# TYPE rate_limit_remaining gauge
+rate_limit_remaining 4999
+# HELP requests_total Requests made.
+# TYPE requests_total counter
+requests_total{method="GET",status="200"} 2
+requests_total{method="POST",status="500"} 3
+`, buf.String())
+}
+
+func TestRegistryCollect(t *testing.T) {
+	r := NewRegistry()
+	stored := r.NewGauge("stored", "Stored items.", "state")
+
+	states := []string{"open", "closed"}
+	r.OnCollect(func() error {
+		stored.Reset()
+		for _, state := range states {
+			stored.Add(1, state)
+		}
+		return nil
+	})
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, r.Write(buf))
+	assert.Contains(t, buf.String(), `stored{state="closed"} 1`)
+
+	states = []string{"open"}
+	buf.Reset()
+	assert.NoError(t, r.Write(buf))
+	assert.NotContains(t, buf.String(), "closed", "Reset should drop stale series")
+
+	r.OnCollect(func() error {
+		return fmt.Errorf("db unavailable")
+	})
+	assert.EqualError(t, r.Write(buf), "db unavailable")
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestRegistryHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("syncs_total", "Syncs run.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "syncs_total 1\n")
+}
+
+func TestRegistryMisuse(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("syncs_total", "Syncs run.", "target")
+
+	assert.Panics(t, func() { r.NewGauge("syncs_total", "Again.") }, "Names must be unique")
+	assert.Panics(t, func() { c.Inc() }, "Every label needs a value")
+	assert.Panics(t, func() { c.Add(-1, "coreos") }, "Counters can't decrease")
+}
@@ -0,0 +1,215 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/doodles526/gogitpr/api"
+)
+
+// PullRequestEvent is delivered when a pull request is opened, edited,
+// closed, reopened, synchronized, labeled...
+type PullRequestEvent struct {
+	Action      string              `json:"action"`
+	Number      int                 `json:"number"`
+	PullRequest api.PullRequestData `json:"pull_request"`
+	Repository  api.RepoData        `json:"repository"`
+	Sender      api.UserData        `json:"sender"`
+}
+
+// PullRequestReviewEvent is delivered when a review is submitted, edited or
+// dismissed
+type PullRequestReviewEvent struct {
+	Action      string              `json:"action"`
+	Review      api.ReviewData      `json:"review"`
+	PullRequest api.PullRequestData `json:"pull_request"`
+	Repository  api.RepoData        `json:"repository"`
+	Sender      api.UserData        `json:"sender"`
+}
+
+// IssueCommentEvent is delivered when a comment on an issue or pull request
+// is created, edited or deleted
+type IssueCommentEvent struct {
+	Action     string          `json:"action"`
+	Issue      api.IssueData   `json:"issue"`
+	Comment    api.CommentData `json:"comment"`
+	Repository api.RepoData    `json:"repository"`
+	Sender     api.UserData    `json:"sender"`
+}
+
+// StatusEvent is delivered when the status of a commit changes
+type StatusEvent struct {
+	ID          int          `json:"id"`
+	Sha         string       `json:"sha"`
+	Name        string       `json:"name"`
+	State       string       `json:"state"`
+	Context     string       `json:"context"`
+	Description string       `json:"description"`
+	TargetURL   string       `json:"target_url"`
+	Repository  api.RepoData `json:"repository"`
+	Sender      api.UserData `json:"sender"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// decodeError marks a payload which could not be decoded, as opposed to a
+// failure to apply it
+type decodeError struct {
+	err error
+}
+
+func (e *decodeError) Error() string {
+	return "decoding payload: " + e.err.Error()
+}
+
+func decode(payload []byte, v interface{}) error {
+	if err := json.Unmarshal(payload, v); err != nil {
+		return &decodeError{err}
+	}
+
+	return nil
+}
+
+// apply upserts the event into the DB, returning false for events which are
+// not handled
+func (h *handler) apply(event string, payload []byte) (bool, error) {
+	switch event {
+	case "ping":
+		return true, nil
+	case "pull_request":
+		e := new(PullRequestEvent)
+		if err := decode(payload, e); err != nil {
+			return true, err
+		}
+		return true, h.applyPullRequest(e)
+	case "pull_request_review":
+		e := new(PullRequestReviewEvent)
+		if err := decode(payload, e); err != nil {
+			return true, err
+		}
+		return true, h.applyPullRequestReview(e)
+	case "issue_comment":
+		e := new(IssueCommentEvent)
+		if err := decode(payload, e); err != nil {
+			return true, err
+		}
+		return true, h.applyIssueComment(e)
+	case "status":
+		e := new(StatusEvent)
+		if err := decode(payload, e); err != nil {
+			return true, err
+		}
+		return true, h.applyStatus(e)
+	default:
+		return false, nil
+	}
+}
+
+func (h *handler) applyPullRequest(e *PullRequestEvent) error {
+	// deliveries may be redelivered or arrive out of order, so never let an
+	// older event overwrite what we have
+	pr, ok, err := h.db.GetPullRequestByID(e.PullRequest.ID)
+	if err != nil {
+		return err
+	}
+	if ok && !pr.UpdatedAt.Before(e.PullRequest.UpdatedAt) {
+		return nil
+	}
+
+	if err := h.db.StorePullRequest(e.PullRequest); err != nil {
+		return err
+	}
+
+	return h.db.StoreClosingIssues(e.PullRequest.ID, e.PullRequest.ClosingReferences())
+}
+
+func (h *handler) applyPullRequestReview(e *PullRequestReviewEvent) error {
+	// the PR of a review event omits some fields, so keep what we have
+	pr, ok, err := h.db.GetPullRequestByID(e.PullRequest.ID)
+	if err != nil {
+		return err
+	}
+	if !ok || pr.UpdatedAt.Before(e.PullRequest.UpdatedAt) {
+		if err := h.db.StorePullRequest(e.PullRequest); err != nil {
+			return err
+		}
+	}
+
+	return h.db.StoreReview(e.PullRequest.ID, e.Review)
+}
+
+func (h *handler) applyIssueComment(e *IssueCommentEvent) error {
+	if !e.Issue.IsPullRequest() {
+		return nil
+	}
+
+	pr, ok, err := h.db.GetPullRequestByNumber(e.Repository.FullName, e.Issue.Number)
+	if err != nil || !ok {
+		// we only track comments on PRs we already know about
+		return err
+	}
+
+	pr.Comments = e.Issue.Comments
+	if pr.UpdatedAt.Before(e.Issue.UpdatedAt) {
+		pr.UpdatedAt = e.Issue.UpdatedAt
+	}
+
+	return h.db.StorePullRequest(pr)
+}
+
+func (h *handler) applyStatus(e *StatusEvent) error {
+	prs, err := h.db.GetFilterPullRequests(func(pr api.PullRequestData) (bool, error) {
+		return pr.State == "open" && pr.Head.Sha == e.Sha, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, pr := range prs {
+		state, err := h.ciState(pr, e)
+		if err != nil {
+			return err
+		}
+
+		if err := h.db.StoreCIState(pr.ID, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *handler) ciState(pr api.PullRequestData, e *StatusEvent) (api.CIState, error) {
+	if h.gh == nil {
+		// the event is for a single context, so can't clear a failure or
+		// pending state stored for another
+		stored, _, err := h.db.GetCIState(pr.ID)
+		if err != nil {
+			return api.CIStateNone, err
+		}
+
+		return mergeCIStates(stored, api.AggregateCIState(&api.CombinedStatusData{
+			State:      e.State,
+			TotalCount: 1,
+		}, nil)), nil
+	}
+
+	statusArgs, err := api.StatusArgsForPullRequest(pr)
+	if err != nil {
+		return api.CIStateNone, err
+	}
+
+	return h.gh.Statuses().CIState(statusArgs)
+}
+
+// mergeCIStates combines CI states as AggregateCIState does: a failure wins,
+// then pending, then success
+func mergeCIStates(a, b api.CIState) api.CIState {
+	for _, state := range []api.CIState{api.CIStateFailure, api.CIStatePending, api.CIStateSuccess} {
+		if a == state || b == state {
+			return state
+		}
+	}
+
+	return api.CIStateNone
+}
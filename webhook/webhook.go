@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// maxPayloadSize is the largest payload github will deliver
+const maxPayloadSize = 25 << 20
+
+var (
+	// ErrMissingSignature reports a delivery without an X-Hub-Signature-256
+	ErrMissingSignature = errors.New("X-Hub-Signature-256 header is missing")
+
+	// ErrInvalidSignature reports a delivery whose signature does not match
+	// its payload
+	ErrInvalidSignature = errors.New("X-Hub-Signature-256 does not match payload")
+)
+
+// Args configures the webhook handler
+type Args struct {
+	// Secret is the secret the webhook was configured with on github
+	Secret string
+	DB     db.DB
+	// GithubAPI is optional. When set, status events recompute the CI state of
+	// the PR from every status and check run of its head. Otherwise the state
+	// of the status delivered is merged into the stored state, so a failed or
+	// pending state is only cleared once the PR is synced again
+	GithubAPI api.GithubAPI
+	Logger    *logrus.Logger
+}
+
+func (a *Args) validate() error {
+	if len(a.Secret) == 0 {
+		return argMissingError("Secret")
+	}
+
+	if a.DB == nil {
+		return argMissingError("DB")
+	}
+
+	if a.Logger == nil {
+		return argMissingError("Logger")
+	}
+
+	return nil
+}
+
+type handler struct {
+	secret []byte
+	db     db.DB
+	gh     api.GithubAPI
+	logger *logrus.Entry
+}
+
+// NewHandler returns an http.Handler which receives github webhook deliveries
+// and applies them to the DB
+func NewHandler(args *Args) (http.Handler, error) {
+	if err := args.validate(); err != nil {
+		return nil, err
+	}
+
+	return &handler{
+		secret: []byte(args.Secret),
+		db:     args.DB,
+		gh:     args.GithubAPI,
+		logger: args.Logger.WithFields(logrus.Fields{"prefix": "Webhook"}),
+	}, nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := ValidateSignature(h.secret, r.Header.Get("X-Hub-Signature-256"), payload); err != nil {
+		h.logger.Warnf("rejecting delivery %s - %v", r.Header.Get("X-GitHub-Delivery"), err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	if len(event) == 0 {
+		http.Error(w, "X-GitHub-Event header is missing", http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Debugf("received %s delivery %s", event, r.Header.Get("X-GitHub-Delivery"))
+
+	handled, err := h.apply(event, payload)
+	if err != nil {
+		if _, ok := errors.Cause(err).(*decodeError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Errorf("applying %s delivery %s - %v", event, r.Header.Get("X-GitHub-Delivery"), err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !handled {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ValidateSignature checks signature, the value of an X-Hub-Signature-256
+// header, is the HMAC of payload keyed by secret
+func ValidateSignature(secret []byte, signature string, payload []byte) error {
+	if len(signature) == 0 {
+		return ErrMissingSignature
+	}
+
+	if !strings.HasPrefix(signature, "sha256=") {
+		return ErrInvalidSignature
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal(got, Sign(secret, payload)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Sign returns the HMAC of payload keyed by secret, as github computes it for
+// X-Hub-Signature-256
+func Sign(secret, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+func argMissingError(field string) error {
+	return fmt.Errorf("%s must be set in webhook Args", field)
+}
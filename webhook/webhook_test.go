@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/doodles526/gogitpr/api"
+	"github.com/doodles526/gogitpr/db"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "It's a Secret to Everybody"
+
+func newTestHandler(t *testing.T) (http.Handler, db.DB) {
+	d, err := db.NewDB(&db.Args{Logger: logrus.New()})
+	assert.NoError(t, err)
+
+	h, err := NewHandler(&Args{
+		Secret: testSecret,
+		DB:     d,
+		Logger: logrus.New(),
+	})
+	assert.NoError(t, err)
+
+	return h, d
+}
+
+// replay delivers the recorded payload in testdata/fixture as event
+func replay(t *testing.T, h http.Handler, event, fixture string) *httptest.ResponseRecorder {
+	payload, err := ioutil.ReadFile(filepath.Join("testdata", fixture))
+	assert.NoError(t, err, "Should read fixture")
+
+	return deliver(h, event, payload, "sha256="+hex.EncodeToString(Sign([]byte(testSecret), payload)))
+}
+
+func deliver(h http.Handler, event string, payload []byte, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/webhook", bytes.NewReader(payload))
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+	if len(signature) != 0 {
+		req.Header.Set("X-Hub-Signature-256", signature)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestValidateSignature(t *testing.T) {
+	// example from the github webhook documentation
+	payload := []byte("Hello, World!")
+	signature := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	assert.NoError(t, ValidateSignature([]byte(testSecret), signature, payload))
+	assert.Equal(t, ErrInvalidSignature, ValidateSignature([]byte("wrong"), signature, payload))
+	assert.Equal(t, ErrInvalidSignature, ValidateSignature([]byte(testSecret), "sha1=757107ea", payload))
+	assert.Equal(t, ErrInvalidSignature, ValidateSignature([]byte(testSecret), "sha256=zz", payload))
+	assert.Equal(t, ErrMissingSignature, ValidateSignature([]byte(testSecret), "", payload))
+}
+
+func TestRejectsBadDeliveries(t *testing.T) {
+	h, d := newTestHandler(t)
+
+	payload, err := ioutil.ReadFile(filepath.Join("testdata", "pull_request_opened.json"))
+	assert.NoError(t, err)
+
+	w := deliver(h, "pull_request", payload, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Unsigned delivery should be rejected")
+
+	w = deliver(h, "pull_request", payload, "sha256="+hex.EncodeToString(Sign([]byte("wrong"), payload)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Delivery signed with another secret should be rejected")
+
+	prs, err := d.GetAllPullRequests()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(prs), "Rejected deliveries should not be applied")
+
+	body := []byte("{")
+	w = deliver(h, "pull_request", body, "sha256="+hex.EncodeToString(Sign([]byte(testSecret), body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Malformed payload should be a bad request")
+
+	req := httptest.NewRequest("GET", "/webhook", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	_, err = NewHandler(&Args{DB: d, Logger: logrus.New()})
+	assert.Error(t, err, "Secret should be required")
+}
+
+func TestReplay(t *testing.T) {
+	h, d := newTestHandler(t)
+
+	w := replay(t, h, "ping", "pull_request_opened.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = replay(t, h, "pull_request", "pull_request_opened.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	pr, ok, err := d.GetPullRequestByID(1)
+	assert.NoError(t, err)
+	assert.True(t, ok, "Opened PR should be stored")
+	assert.Equal(t, "open", pr.State)
+
+	refs, _, err := d.GetClosingIssues(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []api.IssueReference{{Owner: "octocat", Repo: "Hello-World", Number: 1346}}, refs)
+
+	w = replay(t, h, "status", "status_failure.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	state, ok, err := d.GetCIState(1)
+	assert.NoError(t, err)
+	assert.True(t, ok, "Status should set the CI state of the PR at that sha")
+	assert.Equal(t, api.CIStateFailure, state)
+
+	w = replay(t, h, "pull_request_review", "pull_request_review_submitted.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	reviews, err := d.GetReviews(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(reviews), "Review should be stored")
+	assert.True(t, reviews[0].IsApproval())
+
+	// replaying the same delivery must not duplicate anything
+	replay(t, h, "pull_request_review", "pull_request_review_submitted.json")
+	reviews, err = d.GetReviews(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(reviews), "Review should be upserted")
+
+	w = replay(t, h, "issue_comment", "issue_comment_created.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	pr, _, err = d.GetPullRequestByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pr.Comments, "Comment should be counted on the PR")
+	assert.Equal(t, "2011-01-26T21:30:00Z", pr.UpdatedAt.Format("2006-01-02T15:04:05Z"))
+
+	w = replay(t, h, "pull_request", "pull_request_closed.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	prs, err := d.GetAllPullRequests()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(prs), "PR should be upserted")
+	assert.True(t, prs[0].IsMerged(), "Closed PR should be merged")
+
+	w = replay(t, h, "watch", "pull_request_opened.json")
+	assert.Equal(t, http.StatusAccepted, w.Code, "Unhandled events should be accepted and ignored")
+}
+
+func TestOutOfOrderPullRequest(t *testing.T) {
+	h, d := newTestHandler(t)
+
+	w := replay(t, h, "pull_request", "pull_request_closed.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = replay(t, h, "pull_request", "pull_request_opened.json")
+	assert.Equal(t, http.StatusOK, w.Code, "Stale deliveries should still be acknowledged")
+
+	pr, ok, err := d.GetPullRequestByID(1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, pr.IsMerged(), "An older event shouldn't reopen a merged PR")
+	assert.Equal(t, "2011-01-27T10:00:00Z", pr.UpdatedAt.Format("2006-01-02T15:04:05Z"))
+}
+
+func TestStatusContexts(t *testing.T) {
+	h, d := newTestHandler(t)
+
+	replay(t, h, "pull_request", "pull_request_opened.json")
+
+	w := replay(t, h, "status", "status_success.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	state, _, err := d.GetCIState(1)
+	assert.NoError(t, err)
+	assert.Equal(t, api.CIStateSuccess, state)
+
+	replay(t, h, "status", "status_failure.json")
+	replay(t, h, "status", "status_success.json")
+
+	state, _, err = d.GetCIState(1)
+	assert.NoError(t, err)
+	assert.Equal(t, api.CIStateFailure, state, "Success of one context shouldn't clear the failure of another")
+}